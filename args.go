@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sysundo/lang"
+)
+
+type optionArg int
+
+const (
+	argNone     optionArg = iota // Değer almaz
+	argRequired                  // Değer zorunlu (--name=değer, --name değer, -xdeğer, -x değer)
+	argOptional                  // Değer isteğe bağlı (sadece --name=değer)
+)
+
+// optionSpec, bir komutun kabul ettiği tek bir seçeneği tanımlar. Aynı
+// anlama gelen kısa ve uzun biçimler tek bir kanonik ad altında toplanır.
+type optionSpec struct {
+	Short byte
+	Long  string
+	Arg   optionArg
+	Name  string
+}
+
+// commandOptions GNU coreutils rm, mv ve cp komutlarının seçenekleri
+var commandOptions = map[string][]optionSpec{
+	"rm": {
+		{'f', "force", argNone, "force"},
+		{'i', "", argNone, "interactive"},
+		{'I', "", argNone, "interactive-once"},
+		{0, "interactive", argOptional, "interactive"},
+		{0, "one-file-system", argNone, "one-file-system"},
+		{0, "no-preserve-root", argNone, "no-preserve-root"},
+		{0, "preserve-root", argOptional, "preserve-root"},
+		{'r', "recursive", argNone, "recursive"},
+		{'R', "", argNone, "recursive"},
+		{'d', "dir", argNone, "dir"},
+		{'v', "verbose", argNone, "verbose"},
+		{0, "help", argNone, "help"},
+		{0, "version", argNone, "version"},
+	},
+	"mv": {
+		{0, "backup", argOptional, "backup"},
+		{'b', "", argNone, "backup"},
+		{0, "debug", argNone, "debug"},
+		{0, "exchange", argNone, "exchange"},
+		{'f', "force", argNone, "force"},
+		{'i', "interactive", argNone, "interactive"},
+		{'n', "no-clobber", argNone, "no-clobber"},
+		{0, "no-copy", argNone, "no-copy"},
+		{0, "strip-trailing-slashes", argNone, "strip-trailing-slashes"},
+		{'S', "suffix", argRequired, "suffix"},
+		{'t', "target-directory", argRequired, "target-directory"},
+		{'T', "no-target-directory", argNone, "no-target-directory"},
+		{0, "update", argOptional, "update"},
+		{'u', "", argNone, "update"},
+		{'v', "verbose", argNone, "verbose"},
+		{'Z', "context", argNone, "context"},
+		{0, "help", argNone, "help"},
+		{0, "version", argNone, "version"},
+	},
+	"cp": {
+		{'a', "archive", argNone, "archive"},
+		{0, "attributes-only", argNone, "attributes-only"},
+		{0, "backup", argOptional, "backup"},
+		{'b', "", argNone, "backup"},
+		{0, "copy-contents", argNone, "copy-contents"},
+		{'d', "", argNone, "no-dereference"},
+		{0, "debug", argNone, "debug"},
+		{'f', "force", argNone, "force"},
+		{'i', "interactive", argNone, "interactive"},
+		{'H', "", argNone, "dereference-command-line"},
+		{'l', "link", argNone, "link"},
+		{'L', "dereference", argNone, "dereference"},
+		{'n', "no-clobber", argNone, "no-clobber"},
+		{'P', "no-dereference", argNone, "no-dereference"},
+		{'p', "", argNone, "preserve"},
+		{0, "preserve", argOptional, "preserve"},
+		{0, "no-preserve", argRequired, "no-preserve"},
+		{0, "parents", argNone, "parents"},
+		{'R', "recursive", argNone, "recursive"},
+		{'r', "", argNone, "recursive"},
+		{0, "reflink", argOptional, "reflink"},
+		{0, "remove-destination", argNone, "remove-destination"},
+		{0, "sparse", argRequired, "sparse"},
+		{0, "strip-trailing-slashes", argNone, "strip-trailing-slashes"},
+		{'s', "symbolic-link", argNone, "symbolic-link"},
+		{'S', "suffix", argRequired, "suffix"},
+		{'t', "target-directory", argRequired, "target-directory"},
+		{'T', "no-target-directory", argNone, "no-target-directory"},
+		{0, "update", argOptional, "update"},
+		{'u', "", argNone, "update"},
+		{'v', "verbose", argNone, "verbose"},
+		{'x', "one-file-system", argNone, "one-file-system"},
+		{'Z', "", argNone, "context"},
+		{0, "context", argOptional, "context"},
+		{0, "keep-directory-symlink", argNone, "keep-directory-symlink"},
+		{0, "help", argNone, "help"},
+		{0, "version", argNone, "version"},
+	},
+}
+
+// pathTransfer, mv/cp komutunun bir kaynağı nereye taşıyacağını/kopyalayacağını tutar.
+type pathTransfer struct {
+	Source      string
+	Destination string
+}
+
+// commandPlan, bir rm/mv/cp çağrısının GNU coreutils'in yorumlayacağı
+// şekilde çözümlenmiş halidir.
+type commandPlan struct {
+	Command     string
+	Recursive   bool           // -r/-R/--recursive (cp için -a de)
+	RemoveDirs  bool           // rm -d: boş dizinleri de sil
+	NoClobber   bool           // -n veya --update=none: var olan hedeflere dokunma
+	UpdateOlder bool           // -u: sadece hedef kaynaktan eskiyse üzerine yaz
+	Parents     bool           // cp --parents
+	Removals    []string       // rm: silinecek yollar
+	Transfers   []pathTransfer // mv/cp: kaynak → hedef eşleşmeleri
+}
+
+// Sources komutun doğrudan üzerinde çalıştığı kaynak yolları döndürür.
+func (p *commandPlan) Sources() []string {
+	if p.Command == "rm" {
+		return p.Removals
+	}
+
+	var sources []string
+	for _, transfer := range p.Transfers {
+		sources = append(sources, transfer.Source)
+	}
+	return sources
+}
+
+// parseCommandArgs argümanları komutun seçenek tablosuna göre ayrıştırır ve
+// komutun etkileyeceği kaynak ve hedef yolları hesaplar.
+func parseCommandArgs(command string, args []string) (*commandPlan, error) {
+	specs, ok := commandOptions[command]
+	if !ok {
+		return nil, fmt.Errorf(lang.Get("unsupported_command"), command)
+	}
+
+	plan := &commandPlan{Command: command}
+	var operands []string
+	var targetDir string
+	noTargetDir := false
+	informational := false
+
+	// GNU getopt gibi seçenekler ile operandların karışmasına izin ver
+	permute := os.Getenv("POSIXLY_CORRECT") == ""
+	optionsDone := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if optionsDone || arg == "-" || !strings.HasPrefix(arg, "-") {
+			operands = append(operands, arg)
+			if !permute {
+				optionsDone = true
+			}
+			continue
+		}
+
+		if arg == "--" {
+			optionsDone = true
+			continue
+		}
+
+		// Her argüman bir veya daha fazla (kısa seçenek kümesi) seçenek üretir
+		var parsed []parsedOption
+		var err error
+		if strings.HasPrefix(arg, "--") {
+			var opt parsedOption
+			opt, i, err = parseLongOption(command, specs, args, i)
+			parsed = append(parsed, opt)
+		} else {
+			parsed, i, err = parseShortOptions(command, specs, args, i)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, opt := range parsed {
+			switch opt.Name {
+			case "recursive", "archive":
+				plan.Recursive = true
+			case "dir":
+				plan.RemoveDirs = true
+			case "no-clobber":
+				plan.NoClobber = true
+			case "force", "interactive":
+				// mv'de -f, -i ve -n'den sonuncusu geçerlidir
+				if command == "mv" {
+					plan.NoClobber = false
+				}
+			case "update":
+				switch opt.Value {
+				case "", "older":
+					plan.UpdateOlder = true
+				case "none", "none-fail":
+					plan.NoClobber = true
+				case "all":
+					plan.UpdateOlder = false
+				}
+			case "parents":
+				plan.Parents = true
+			case "target-directory":
+				if targetDir != "" {
+					return nil, fmt.Errorf(lang.Get("multiple_target_dirs"), command)
+				}
+				targetDir = opt.Value
+			case "no-target-directory":
+				noTargetDir = true
+			case "help", "version":
+				informational = true
+			}
+		}
+	}
+
+	// --help ve --version hiçbir dosyaya dokunmaz
+	if informational {
+		return plan, nil
+	}
+
+	if command == "rm" {
+		plan.Removals = operands
+		return plan, nil
+	}
+
+	transfers, err := resolveTransfers(command, operands, targetDir, noTargetDir, plan.Parents)
+	if err != nil {
+		return nil, err
+	}
+	plan.Transfers = transfers
+
+	return plan, nil
+}
+
+// fallbackCommandPlan, seçenekler tanınamadığında (örneğin BSD rm -P veya
+// cp -c) kullanılır: tire ile başlamayan her argüman yol sayılır. Seçeneklerin
+// anlamı bilinmediği için dizinler her durumda özyinelemeli dolaşılır; fazladan
+// yedeklemek eksik yedeklemekten iyidir.
+func fallbackCommandPlan(command string, args []string) *commandPlan {
+	plan := &commandPlan{Command: command, Recursive: true}
+
+	var operands []string
+	optionsDone := false
+	for _, arg := range args {
+		if !optionsDone && arg == "--" {
+			optionsDone = true
+			continue
+		}
+		if optionsDone || arg == "-" || !strings.HasPrefix(arg, "-") {
+			operands = append(operands, arg)
+		}
+	}
+
+	if command == "rm" {
+		plan.Removals = operands
+		return plan
+	}
+
+	plan.Transfers, _ = resolveTransfers(command, operands, "", false, false)
+	return plan
+}
+
+type parsedOption struct {
+	Name  string
+	Value string
+}
+
+func parseLongOption(command string, specs []optionSpec, args []string, i int) (parsedOption, int, error) {
+	name := strings.TrimPrefix(args[i], "--")
+	value := ""
+	hasValue := false
+	if idx := strings.Index(name, "="); idx >= 0 {
+		name, value, hasValue = name[:idx], name[idx+1:], true
+	}
+
+	var spec *optionSpec
+	for j := range specs {
+		if specs[j].Long != "" && specs[j].Long == name {
+			spec = &specs[j]
+			break
+		}
+	}
+
+	// Tam eşleşme yoksa GNU gibi benzersiz kısaltmaları kabul et
+	if spec == nil {
+		for j := range specs {
+			if specs[j].Long == "" || !strings.HasPrefix(specs[j].Long, name) {
+				continue
+			}
+			if spec != nil && spec.Name != specs[j].Name {
+				return parsedOption{}, i, fmt.Errorf(lang.Get("ambiguous_option"), args[i], command)
+			}
+			spec = &specs[j]
+		}
+	}
+
+	if spec == nil {
+		return parsedOption{}, i, fmt.Errorf(lang.Get("unrecognized_option"), args[i], command)
+	}
+
+	switch spec.Arg {
+	case argNone:
+		if hasValue {
+			return parsedOption{}, i, fmt.Errorf(lang.Get("option_takes_no_argument"), "--"+spec.Long, command)
+		}
+	case argRequired:
+		if !hasValue {
+			if i+1 >= len(args) {
+				return parsedOption{}, i, fmt.Errorf(lang.Get("option_requires_argument"), "--"+spec.Long, command)
+			}
+			i++
+			value = args[i]
+		}
+	}
+
+	return parsedOption{Name: spec.Name, Value: value}, i, nil
+}
+
+func parseShortOptions(command string, specs []optionSpec, args []string, i int) ([]parsedOption, int, error) {
+	cluster := args[i][1:]
+	var parsed []parsedOption
+
+	for j := 0; j < len(cluster); j++ {
+		var spec *optionSpec
+		for k := range specs {
+			if specs[k].Short == cluster[j] {
+				spec = &specs[k]
+				break
+			}
+		}
+
+		if spec == nil {
+			return nil, i, fmt.Errorf(lang.Get("unrecognized_option"), "-"+string(cluster[j]), command)
+		}
+
+		if spec.Arg != argRequired {
+			parsed = append(parsed, parsedOption{Name: spec.Name})
+			continue
+		}
+
+		// Değer kümenin geri kalanı ya da bir sonraki argümandır
+		value := cluster[j+1:]
+		if value == "" {
+			if i+1 >= len(args) {
+				return nil, i, fmt.Errorf(lang.Get("option_requires_argument"), "-"+string(cluster[j]), command)
+			}
+			i++
+			value = args[i]
+		}
+		parsed = append(parsed, parsedOption{Name: spec.Name, Value: value})
+		break
+	}
+
+	return parsed, i, nil
+}
+
+// resolveTransfers mv/cp operandlarından her kaynağın gideceği hedefi hesaplar.
+// Komutun kendisinin hata vereceği durumlarda (eksik hedef, dizin olmayan
+// hedef) hiçbir dosya etkilenmeyeceği için boş liste döner.
+func resolveTransfers(command string, operands []string, targetDir string, noTargetDir, parents bool) ([]pathTransfer, error) {
+	if targetDir != "" && noTargetDir {
+		return nil, fmt.Errorf(lang.Get("conflicting_target_options"), command)
+	}
+
+	var sources []string
+	if targetDir != "" {
+		sources = operands
+	} else {
+		if len(operands) < 2 {
+			return nil, nil
+		}
+		sources = operands[:len(operands)-1]
+		last := operands[len(operands)-1]
+
+		// -T veya tek kaynaklı, dizin olmayan hedef: doğrudan hedef dosya
+		if noTargetDir || (len(operands) == 2 && !parents && !isDirectory(last)) {
+			if len(operands) != 2 {
+				return nil, nil
+			}
+			return []pathTransfer{{Source: sources[0], Destination: last}}, nil
+		}
+		targetDir = last
+	}
+
+	if !isDirectory(targetDir) {
+		return nil, nil
+	}
+
+	var transfers []pathTransfer
+	for _, source := range sources {
+		name := filepath.Base(strings.TrimRight(source, string(filepath.Separator)))
+		if parents {
+			// cp --parents kaynak yolunu hedef dizinin altında olduğu gibi kurar
+			name = source
+		}
+		transfers = append(transfers, pathTransfer{
+			Source:      source,
+			Destination: filepath.Join(targetDir, name),
+		})
+	}
+
+	return transfers, nil
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommandArgs(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		args      []string
+		posix     bool
+		removals  []string
+		transfers []pathTransfer
+		recursive bool
+		noClobber bool
+		wantErr   bool
+	}{
+		{
+			name:     "rm operands",
+			command:  "rm",
+			args:     []string{"a", "b"},
+			removals: []string{"a", "b"},
+		},
+		{
+			name:      "rm option cluster",
+			command:   "rm",
+			args:      []string{"-rfv", "a"},
+			removals:  []string{"a"},
+			recursive: true,
+		},
+		{
+			name:      "options after operands",
+			command:   "rm",
+			args:      []string{"a", "-r", "b"},
+			removals:  []string{"a", "b"},
+			recursive: true,
+		},
+		{
+			name:     "double dash ends options",
+			command:  "rm",
+			args:     []string{"--", "-r", "-f"},
+			removals: []string{"-r", "-f"},
+		},
+		{
+			name:     "single dash is an operand",
+			command:  "rm",
+			args:     []string{"-"},
+			removals: []string{"-"},
+		},
+		{
+			name:     "POSIXLY_CORRECT stops at first operand",
+			command:  "rm",
+			args:     []string{"a", "-r"},
+			posix:    true,
+			removals: []string{"a", "-r"},
+		},
+		{
+			name:      "long option abbreviation",
+			command:   "rm",
+			args:      []string{"--recur", "a"},
+			removals:  []string{"a"},
+			recursive: true,
+		},
+		{
+			name:    "ambiguous abbreviation",
+			command: "cp",
+			args:    []string{"--no", "a", "b"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown option",
+			command: "rm",
+			args:    []string{"-P", "a"},
+			wantErr: true,
+		},
+		{
+			name:    "long option with argument for argNone",
+			command: "rm",
+			args:    []string{"--force=yes", "a"},
+			wantErr: true,
+		},
+		{
+			name:      "mv into directory",
+			command:   "mv",
+			args:      []string{"a", "b", target},
+			transfers: []pathTransfer{{"a", filepath.Join(target, "a")}, {"b", filepath.Join(target, "b")}},
+		},
+		{
+			name:      "mv to file",
+			command:   "mv",
+			args:      []string{"a", file},
+			transfers: []pathTransfer{{"a", file}},
+		},
+		{
+			name:      "-t with separate value",
+			command:   "cp",
+			args:      []string{"-t", target, "a", "b"},
+			transfers: []pathTransfer{{"a", filepath.Join(target, "a")}, {"b", filepath.Join(target, "b")}},
+		},
+		{
+			name:      "-t value attached in cluster",
+			command:   "cp",
+			args:      []string{"-rt" + target, "a"},
+			transfers: []pathTransfer{{"a", filepath.Join(target, "a")}},
+			recursive: true,
+		},
+		{
+			name:      "--target-directory=",
+			command:   "mv",
+			args:      []string{"--target-directory=" + target, "a"},
+			transfers: []pathTransfer{{"a", filepath.Join(target, "a")}},
+		},
+		{
+			name:    "-t twice",
+			command: "mv",
+			args:    []string{"-t", target, "-t", target, "a"},
+			wantErr: true,
+		},
+		{
+			name:    "-t without value",
+			command: "mv",
+			args:    []string{"a", "-t"},
+			wantErr: true,
+		},
+		{
+			name:    "-t with -T",
+			command: "mv",
+			args:    []string{"-T", "-t", target, "a"},
+			wantErr: true,
+		},
+		{
+			name:      "-T treats directory as file",
+			command:   "mv",
+			args:      []string{"-T", "a", target},
+			transfers: []pathTransfer{{"a", target}},
+		},
+		{
+			name:    "-T with three operands",
+			command: "mv",
+			args:    []string{"-T", "a", "b", target},
+		},
+		{
+			name:      "-n and -f on mv, last wins",
+			command:   "mv",
+			args:      []string{"-nf", "a", file},
+			transfers: []pathTransfer{{"a", file}},
+		},
+		{
+			name:      "cp --update=none",
+			command:   "cp",
+			args:      []string{"--update=none", "a", file},
+			transfers: []pathTransfer{{"a", file}},
+			noClobber: true,
+		},
+		{
+			name:      "cp -a implies recursive",
+			command:   "cp",
+			args:      []string{"-a", "a", target},
+			transfers: []pathTransfer{{"a", filepath.Join(target, "a")}},
+			recursive: true,
+		},
+		{
+			name:    "--help touches nothing",
+			command: "cp",
+			args:    []string{"--help", "a", target},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.posix {
+				t.Setenv("POSIXLY_CORRECT", "1")
+			} else {
+				t.Setenv("POSIXLY_CORRECT", "")
+			}
+
+			plan, err := parseCommandArgs(tt.command, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got plan %+v", plan)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(plan.Removals, tt.removals) {
+				t.Errorf("removals = %q, want %q", plan.Removals, tt.removals)
+			}
+			if !reflect.DeepEqual(plan.Transfers, tt.transfers) {
+				t.Errorf("transfers = %q, want %q", plan.Transfers, tt.transfers)
+			}
+			if plan.Recursive != tt.recursive {
+				t.Errorf("recursive = %v, want %v", plan.Recursive, tt.recursive)
+			}
+			if plan.NoClobber != tt.noClobber {
+				t.Errorf("noClobber = %v, want %v", plan.NoClobber, tt.noClobber)
+			}
+		})
+	}
+}
+
+func TestFallbackCommandPlan(t *testing.T) {
+	dir := t.TempDir()
+
+	plan := fallbackCommandPlan("rm", []string{"-P", "a", "-x", "--", "-b"})
+	if want := []string{"a", "-b"}; !reflect.DeepEqual(plan.Removals, want) {
+		t.Errorf("removals = %q, want %q", plan.Removals, want)
+	}
+	if !plan.Recursive {
+		t.Error("fallback plan should walk directories")
+	}
+
+	plan = fallbackCommandPlan("cp", []string{"-c", "a", "b", dir})
+	want := []pathTransfer{{"a", filepath.Join(dir, "a")}, {"b", filepath.Join(dir, "b")}}
+	if !reflect.DeepEqual(plan.Transfers, want) {
+		t.Errorf("transfers = %q, want %q", plan.Transfers, want)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sysundo/internal/atomicfile"
+	"sysundo/lang"
+	"time"
+)
+
+// Bu kadar süredir değişmemiş geçici dosyalar terk edilmiş sayılır; daha
+// yenileri aynı anda çalışan başka bir sysundo'ya ait olabilir.
+const staleTempAge = time.Hour
+
+// recover, önceki bir çalışmanın çökmesinden kalan yarım dosyaları
+// temizler: depodaki ve ana dizindeki eski geçici dosyaları siler ve
+// geçmişin sonunda yarım kalmış bir kayıt satırı varsa onu keser.
+func (bm *BackupManager) recover() {
+	removed := removeStaleTemps(bm.baseDir) + removeStaleTemps(bm.store.dir)
+
+	truncated, err := bm.history.repairTail()
+	if err != nil {
+		fmt.Printf(lang.Get("recovery_warning")+"\n", err)
+	}
+
+	if removed > 0 || truncated > 0 {
+		fmt.Printf(lang.Get("recovery_done")+"\n", removed, truncated)
+	}
+}
+
+// removeStaleTemps, dizindeki terk edilmiş geçici dosyaları siler ve
+// silinenlerin sayısını döndürür.
+func removeStaleTemps(dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	cutoff := time.Now().Add(-staleTempAge)
+	for _, entry := range entries {
+		// .history-* eski sürümlerin geçmişi yeniden yazarken kullandığı önektir
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasPrefix(name, atomicfile.TempPrefix) || strings.HasPrefix(name, ".history-")) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		if os.Remove(filepath.Join(dir, name)) == nil {
+			removed++
+		}
+	}
+
+	return removed
+}
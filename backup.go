@@ -1,30 +1,108 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sysundo/internal/atomicfile"
 	"sysundo/lang"
 	"time"
 )
 
 type BackupManager struct {
-	backupDir string
+	baseDir   string
+	backupDir string // Eski sürümlerin yedek dosyaları (cache)
+	store     *ObjectStore
+	keys      *KeyManager
+	history   *History
+	random    io.Reader // İşlem ID'lerinin rastgele kaynağı
 }
 
 type BackupRecord struct {
-	Timestamp time.Time        `json:"timestamp"`
-	Command   string           `json:"command"`
-	Args      []string         `json:"args"`
-	Files     []BackupFileInfo `json:"files"`
+	ID          string            `json:"id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Command     string            `json:"command"`
+	Args        []string          `json:"args"`
+	WorkingDir  string            `json:"working_dir,omitempty"`
+	Files       []BackupFileInfo  `json:"files"`
+	Directories []BackupDirInfo   `json:"directories,omitempty"`
+	Effects     *OperationEffects `json:"effects,omitempty"`
+	Pinned      bool              `json:"pinned,omitempty"` // Saklama kurallarından muaf, gc asla silmez
+}
+
+// OperationEffects, komutun dosya sistemi üzerindeki etkisini tanımlar.
+// Geri alma işlemi bu bilgiyle cp'nin oluşturduğu dosyaları siler, mv'nin
+// taşıdığı yolları geri taşır ve üzerine yazılan hedefleri geri yükler.
+// Eski kayıtlarda bulunmaz; o durumda sadece yedekler geri kopyalanır.
+type OperationEffects struct {
+	Created       []string          `json:"created,omitempty"`
+	CreatedHashes map[string]string `json:"created_hashes,omitempty"` // Oluşturulan ve üzerine yazılan dosyaların komuttan sonraki beklenen içerik özeti (kaynağın özeti)
+	CreatedKeyed  bool              `json:"created_keyed,omitempty"`  // CreatedHashes ad anahtarıyla HMAC-SHA256
+	Moved         []MovedPath       `json:"moved,omitempty"`
+	Overwritten   []string          `json:"overwritten,omitempty"`
+}
+
+type MovedPath struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (e *OperationEffects) IsEmpty() bool {
+	return e == nil || (len(e.Created) == 0 && len(e.Moved) == 0 && len(e.Overwritten) == 0)
 }
 
 type BackupFileInfo struct {
-	OriginalPath string `json:"original_path"`
-	BackupPath   string `json:"backup_path"`
-	Size         int64  `json:"size"`
+	OriginalPath string        `json:"original_path"`
+	BackupPath   string        `json:"backup_path,omitempty"` // Sadece eski kayıtlarda: cache içindeki kopya
+	Hash         string        `json:"hash,omitempty"`        // Nesne deposundaki içeriğin SHA-256 özeti
+	Keyed        bool          `json:"keyed,omitempty"`       // Hash ve parça özetleri ad anahtarıyla HMAC-SHA256 (şifreli yedekler)
+	Codec        string        `json:"codec,omitempty"`       // Nesnenin sıkıştırma biçimi
+	StoredSize   int64         `json:"stored_size,omitempty"` // Nesnenin diskte kapladığı boyut
+	Mode         os.FileMode   `json:"mode,omitempty"`
+	Size         int64         `json:"size"`
+	Sparse       bool          `json:"sparse,omitempty"`        // Dosyada boşluklar vardı; geri yüklemede sıfır blokları boşluk bırakılır
+	RootPath     string        `json:"root_path,omitempty"`     // Dosya bir dizin argümanından geldiyse o dizin
+	RelativePath string        `json:"relative_path,omitempty"` // RootPath'e göre göreli yol
+	Role         string        `json:"role,omitempty"`          // Boş: komutun kaynağı, "overwritten": üzerine yazılan hedef, "link_target": bağın hedefi
+	Meta         *FileMetadata `json:"meta,omitempty"`          // Sahiplik, zamanlar ve genişletilmiş öznitelikler
+	LinkTarget   string        `json:"link_target,omitempty"`   // Doluysa kayıt bir sembolik bağdır, içerik saklanmaz
+	LinkGroup    string        `json:"link_group,omitempty"`    // Aynı işlemde aynı inode'a ait sabit bağlar aynı grubu paylaşır
+	Chunks       []ChunkRef    `json:"chunks,omitempty"`        // Büyük dosyalarda içeriğin parçaları; Hash bu durumda dosyanın tamamının özetidir
+}
+
+// ObjectHashes, kaydın depoda kullandığı nesnelerin özetlerini döndürür.
+func (fi *BackupFileInfo) ObjectHashes() []string {
+	if len(fi.Chunks) > 0 {
+		hashes := make([]string, len(fi.Chunks))
+		for i, chunk := range fi.Chunks {
+			hashes[i] = chunk.Hash
+		}
+		return hashes
+	}
+	if fi.Hash != "" {
+		return []string{fi.Hash}
+	}
+	return nil
+}
+
+// Dosya kayıtlarının rolleri
+const (
+	roleOverwritten = "overwritten" // mv/cp'nin üzerine yazacağı mevcut bir hedef dosya
+	roleLinkTarget  = "link_target" // Yedeklenen bir sembolik bağın işaret ettiği dosya
+)
+
+// BackupDirInfo, özyinelemeli işlemlerde etkilenen bir dizini tanımlar.
+// Geri yüklemede boş dizinler de dahil olmak üzere ağaç yeniden kurulur.
+type BackupDirInfo struct {
+	Path         string        `json:"path"`
+	RootPath     string        `json:"root_path"`
+	RelativePath string        `json:"relative_path"`
+	Mode         os.FileMode   `json:"mode"`
+	Meta         *FileMetadata `json:"meta,omitempty"`
 }
 
 func NewBackupManager() *BackupManager {
@@ -34,86 +112,198 @@ func NewBackupManager() *BackupManager {
 		homeDir = "."
 	}
 
-	backupDir := filepath.Join(homeDir, ".sysundo", "cache")
+	baseDir := filepath.Join(homeDir, ".sysundo")
+	objectsDir := filepath.Join(baseDir, "objects")
 
 	// Yedekleme dizinini oluştur
-	err = os.MkdirAll(backupDir, 0755)
+	err = os.MkdirAll(objectsDir, 0755)
 	if err != nil {
 		fmt.Printf(lang.Get("backup_dir_create_warning")+"\n", err)
 	}
 
-	return &BackupManager{
-		backupDir: backupDir,
+	keys := NewKeyManager(filepath.Join(baseDir, "keyring.json"))
+
+	bm := &BackupManager{
+		baseDir:   baseDir,
+		backupDir: filepath.Join(baseDir, "cache"),
+		store:     NewObjectStore(objectsDir, keys),
+		keys:      keys,
+		history:   NewHistory(filepath.Join(baseDir, "history.jsonl")),
+		random:    rand.Reader,
+	}
+
+	// Eski sürümlerden kalan last_backup.json dosyasını geçmişe taşı
+	if err := bm.migrateLegacyRecord(); err != nil {
+		fmt.Printf(lang.Get("history_migrate_warning")+"\n", err)
 	}
+
+	return bm
 }
 
-func (bm *BackupManager) BackupFile(filePath string) (string, error) {
+func (bm *BackupManager) BackupFile(filePath string, config *Config) (*BackupFileInfo, error) {
 	// Mutlak yol al
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
-		return "", fmt.Errorf(lang.Get("absolute_path_error"), err)
+		return nil, fmt.Errorf(lang.Get("absolute_path_error"), err)
 	}
 
 	// Dosya bilgilerini al
-	_, err = os.Stat(absPath)
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf(lang.Get("file_info_error"), err)
+	}
+
+	// Boyut sınırını aşan dosyalar (parçalı yedekleme açıksa) parçalar halinde saklanır
+	if config.ChunkedBackup && info.Size() > config.MaxFileSize {
+		return bm.backupChunked(absPath, info, config)
+	}
+
+	// İçeriği nesne deposuna ekle, aynı içerik daha önce yedeklendiyse tekrar yazılmaz
+	encrypt := config.Encryption != encryptionNone
+	object, err := bm.store.Put(absPath, PutOptions{
+		Codec:   config.Compression,
+		Level:   config.CompressionLevel,
+		Encrypt: encrypt,
+	})
 	if err != nil {
-		return "", fmt.Errorf(lang.Get("file_info_error"), err)
+		return nil, fmt.Errorf(lang.Get("file_copy_error"), err)
 	}
 
-	// Yedekleme dosya adını oluştur
-	timestamp := time.Now().Format("20060102_150405")
-	baseFileName := filepath.Base(absPath)
-	backupFileName := fmt.Sprintf("%s_%s_%s", timestamp,
-		bm.sanitizeFileName(baseFileName), bm.generateID())
-	backupPath := filepath.Join(bm.backupDir, backupFileName)
+	return &BackupFileInfo{
+		OriginalPath: absPath,
+		Hash:         object.Hash,
+		Keyed:        encrypt,
+		Codec:        object.Codec,
+		StoredSize:   object.StoredSize,
+		Mode:         modeBits(info),
+		Size:         object.Size,
+		Sparse:       isSparse(info),
+		Meta:         captureMetadata(absPath, info),
+	}, nil
+}
+
+// BackupSymlink, sembolik bağı izlemeden, işaret ettiği yolla birlikte kaydeder.
+func (bm *BackupManager) BackupSymlink(linkPath string) (*BackupFileInfo, error) {
+	absPath, err := filepath.Abs(linkPath)
+	if err != nil {
+		return nil, fmt.Errorf(lang.Get("absolute_path_error"), err)
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf(lang.Get("file_info_error"), err)
+	}
 
-	// Dosyayı kopyala
-	err = bm.copyFile(absPath, backupPath)
+	target, err := os.Readlink(absPath)
 	if err != nil {
-		return "", fmt.Errorf(lang.Get("file_copy_error"), err)
+		return nil, fmt.Errorf(lang.Get("file_info_error"), err)
 	}
 
-	return backupPath, nil
+	meta := &FileMetadata{
+		ModTime: info.ModTime(),
+		Owner:   fileOwner(info),
+	}
+
+	return &BackupFileInfo{
+		OriginalPath: absPath,
+		LinkTarget:   target,
+		Meta:         meta,
+	}, nil
 }
 
-func (bm *BackupManager) CreateBackupRecord(backupPaths map[string]string, command string, args []string) error {
-	var fileInfos []BackupFileInfo
+// OpenBackup, bir dosya kaydının yedek içeriğini okumak için açar. Eski
+// kayıtlar cache içindeki kopyayı, yeniler nesne deposunu kullanır.
+func (bm *BackupManager) OpenBackup(fileInfo BackupFileInfo) (io.ReadCloser, error) {
+	if len(fileInfo.Chunks) > 0 {
+		return &chunkReader{store: bm.store, chunks: fileInfo.Chunks}, nil
+	}
 
-	for originalPath, backupPath := range backupPaths {
-		info, err := os.Stat(backupPath)
-		if err != nil {
-			continue
-		}
+	if fileInfo.Hash != "" {
+		return bm.store.Open(fileInfo.Hash)
+	}
+
+	return os.Open(fileInfo.BackupPath)
+}
 
-		fileInfos = append(fileInfos, BackupFileInfo{
-			OriginalPath: originalPath,
-			BackupPath:   backupPath,
-			Size:         info.Size(),
-		})
+// CreateBackupRecord işlemi benzersiz bir ID ile geçmişe ekler. Çağıran
+// depo kilidini tutmalıdır.
+func (bm *BackupManager) CreateBackupRecord(fileInfos []BackupFileInfo, dirInfos []BackupDirInfo, effects *OperationEffects, command string, args []string) (*BackupRecord, error) {
+	// Göreli argümanların anlamı için çalışma dizinini de kaydet
+	workingDir, _ := os.Getwd()
+
+	now := time.Now()
+	id, err := bm.newOperationID(now)
+	if err != nil {
+		return nil, err
 	}
 
 	record := BackupRecord{
-		Timestamp: time.Now(),
-		Command:   command,
-		Args:      args,
-		Files:     fileInfos,
+		ID:          id,
+		Timestamp:   now,
+		Command:     command,
+		Args:        args,
+		WorkingDir:  workingDir,
+		Files:       fileInfos,
+		Directories: dirInfos,
+		Effects:     effects,
 	}
 
-	// JSON olarak kaydet
-	recordPath := filepath.Join(bm.backupDir, "last_backup.json")
-	data, err := json.MarshalIndent(record, "", "  ")
+	// Geçmişin sonuna ekle
+	err = bm.history.Append(record)
 	if err != nil {
-		return fmt.Errorf(lang.Get("json_marshal_error"), err)
+		return nil, err
 	}
 
-	err = os.WriteFile(recordPath, data, 0644)
+	return &record, nil
+}
+
+// migrateLegacyRecord, tek kayıtlık eski last_backup.json dosyasını
+// geçmiş günlüğüne ekler ve ardından siler.
+func (bm *BackupManager) migrateLegacyRecord() error {
+	legacyPath := filepath.Join(bm.backupDir, "last_backup.json")
+	if _, err := os.Stat(legacyPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	lock, err := bm.Lock()
 	if err != nil {
-		return fmt.Errorf(lang.Get("record_file_write_error"), err)
+		return err
 	}
+	defer lock.Unlock()
 
-	return nil
+	// Kilit beklenirken başka bir süreç taşımış olabilir
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var record BackupRecord
+	err = json.Unmarshal(data, &record)
+	if err != nil {
+		return fmt.Errorf(lang.Get("backup_record_read_error"), err)
+	}
+
+	if record.ID == "" {
+		record.ID, err = bm.newOperationID(record.Timestamp)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = bm.history.Append(record)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(legacyPath)
 }
 
+// copyFile, kaynağı hedefin yanında geçici bir dosyaya kopyalar ve diske
+// işledikten sonra hedefin yerine taşır; yarıda kalan bir kopya hedefte
+// yarım bir dosya bırakmaz.
 func (bm *BackupManager) copyFile(src, dst string) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -121,46 +311,95 @@ func (bm *BackupManager) copyFile(src, dst string) error {
 	}
 	defer srcFile.Close()
 
-	dstFile, err := os.Create(dst)
+	srcInfo, err := srcFile.Stat()
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
+	tmpFile, err := os.CreateTemp(filepath.Dir(dst), ".sysundo-*")
 	if err != nil {
 		return err
 	}
+	tmpPath := tmpFile.Name()
+
+	_, err = cloneFile(tmpFile, srcFile)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
 
 	// Dosya izinlerini kopyala
-	srcInfo, err := os.Stat(src)
+	if err == nil {
+		err = os.Chmod(tmpPath, srcInfo.Mode())
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, dst)
+	}
 	if err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
 
-	err = os.Chmod(dst, srcInfo.Mode())
+	return atomicfile.SyncDir(filepath.Dir(dst))
+}
+
+// hashFile, dosyanın içerik özetini yedek kayıtlarındaki Hash ile aynı
+// biçimde hesaplar; keyed ise ad anahtarıyla.
+func (bm *BackupManager) hashFile(path string, keyed bool) (string, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer file.Close()
 
-	return nil
+	hasher, err := bm.store.newHasher(keyed)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-func (bm *BackupManager) sanitizeFileName(fileName string) string {
-	// Dosya adından güvenli olmayan karakterleri temizle
-	sanitized := ""
-	for _, r := range fileName {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
-			(r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
-			sanitized += string(r)
-		} else {
-			sanitized += "_"
+// maxIDAttempts, geçmişte zaten kullanılan bir ID çekildiğinde kaç kez
+// yeniden deneneceği. Sağlam bir rastgele kaynakla ikinci deneme bile
+// neredeyse hiç gerekmez.
+const maxIDAttempts = 16
+
+// newOperationID, t zamanına ait ve geçmişte henüz kullanılmamış bir işlem
+// ID'si üretir. Çağıran depo kilidini tutmalıdır; böylece aynı anda çalışan
+// iki süreç aynı ID'yi seçemez.
+func (bm *BackupManager) newOperationID(t time.Time) (string, error) {
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id := bm.generateOperationID(t)
+		used, err := bm.history.HasID(id)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return id, nil
 		}
 	}
-	return sanitized
+
+	return "", fmt.Errorf(lang.Get("operation_id_error"), maxIDAttempts)
 }
 
+func (bm *BackupManager) generateOperationID(t time.Time) string {
+	// İşlem ID'si zaman damgası ve rastgele bir sonekten oluşur
+	return fmt.Sprintf("%s-%s", t.Format("20060102150405"), bm.generateID())
+}
+
+// generateID, aynı saniyede başlayan işlemleri ayırt etmek için 32 bitlik
+// rastgele bir değer üretir. Benzersizlik newOperationID'de geçmişe karşı
+// kontrol edilir.
 func (bm *BackupManager) generateID() string {
-	// Basit bir ID üretici
-	return fmt.Sprintf("%d", time.Now().UnixNano()%100000)
+	var buf [4]byte
+	if _, err := io.ReadFull(bm.random, buf[:]); err != nil {
+		return fmt.Sprintf("%08x", uint32(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(buf[:])
 }
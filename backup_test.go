@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestOperationIDCollision(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	bm := NewBackupManager()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	taken := "20260102030405-00000001"
+	if err := bm.history.Append(BackupRecord{ID: taken, Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	// İlk çekiliş geçmişteki ID ile çakışır, ikincisi boştadır
+	random := bytes.NewReader([]byte{0, 0, 0, 1, 0, 0, 0, 2})
+	bm.random = random
+
+	id, err := bm.newOperationID(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "20260102030405-00000002"; id != want {
+		t.Fatalf("id = %s, want %s", id, want)
+	}
+	if random.Len() != 0 {
+		t.Errorf("expected a second draw after the collision, %d bytes unread", random.Len())
+	}
+}
+
+func TestOperationIDExhausted(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	bm := NewBackupManager()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := bm.history.Append(BackupRecord{ID: "20260102030405-00000001", Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hep aynı değeri veren bir kaynak sonsuz döngüye değil hataya yol açar
+	bm.random = bytes.NewReader(bytes.Repeat([]byte{0, 0, 0, 1}, maxIDAttempts))
+	if id, err := bm.newOperationID(now); err == nil {
+		t.Fatalf("expected an error, got id %s", id)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sysundo/lang"
+)
+
+// Büyük dosyalar içerik tanımlı parçalara (content-defined chunking)
+// bölünerek saklanır. Parça sınırları içeriğe göre belirlendiği için bir
+// dosyanın ortasına veri eklense bile sadece değişen bölgenin parçaları
+// yeniden yazılır; diğerleri depoda tekilleştirilir.
+const (
+	chunkMinSize = 512 * 1024
+	chunkMaxSize = 4 * 1024 * 1024
+	chunkAvgBits = 20 // Ortalama parça boyutu ~1MB (2^20)
+)
+
+// ChunkRef, parçalı bir yedeğin bir parçasını tanımlar.
+type ChunkRef struct {
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// gearTable, gear özeti için sabit rastgele değerler. Parça sınırlarının
+// sürümler arasında değişmemesi için sabit bir tohumla (splitmix64) üretilir.
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	seed := uint64(0x5359_5355_4e44_4f21) // "SYSUNDO!"
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// chunker, bir okuyucuyu içerik tanımlı parçalara böler.
+type chunker struct {
+	r   io.Reader
+	buf []byte
+	n   int
+	eof bool
+}
+
+func newChunker(r io.Reader) *chunker {
+	return &chunker{
+		r:   r,
+		buf: make([]byte, chunkMaxSize),
+	}
+}
+
+// Next bir sonraki parçayı döndürür; parça kalmadığında io.EOF döner.
+func (c *chunker) Next() ([]byte, error) {
+	for c.n < len(c.buf) && !c.eof {
+		m, err := c.r.Read(c.buf[c.n:])
+		c.n += m
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.n == 0 {
+		return nil, io.EOF
+	}
+
+	cut := cutPoint(c.buf[:c.n])
+	chunk := make([]byte, cut)
+	copy(chunk, c.buf[:cut])
+	c.n = copy(c.buf, c.buf[cut:c.n])
+
+	return chunk, nil
+}
+
+// cutPoint, gear özetinin üst bitleri sıfır olduğunda parçayı keser.
+// Özet son 64 baytın bir fonksiyonu olduğundan sınırlar içeriğe bağlıdır.
+func cutPoint(data []byte) int {
+	if len(data) <= chunkMinSize {
+		return len(data)
+	}
+
+	var hash uint64
+	for i := chunkMinSize; i < len(data); i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash>>(64-chunkAvgBits) == 0 {
+			return i + 1
+		}
+	}
+
+	return len(data)
+}
+
+// backupChunked, dosyayı parçalar halinde depoya ekler. Her parça kendi
+// başına geçici dosya ve rename ile yazıldığı için yarıda kalan bir yedekleme
+// depoda sadece tam parçalar bırakır; tekrar denendiğinde bu parçalar yeniden
+// yazılmaz. Dosyanın tamamının özeti doğrulama için ayrıca tutulur.
+func (bm *BackupManager) backupChunked(absPath string, info os.FileInfo, config *Config) (*BackupFileInfo, error) {
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, fmt.Errorf(lang.Get("file_copy_error"), err)
+	}
+	defer file.Close()
+
+	opts := PutOptions{
+		Codec:   config.Compression,
+		Level:   config.CompressionLevel,
+		Encrypt: config.Encryption != encryptionNone,
+	}
+
+	hasher, err := bm.store.newHasher(opts.Encrypt)
+	if err != nil {
+		return nil, fmt.Errorf(lang.Get("file_copy_error"), err)
+	}
+	chunks := newChunker(io.TeeReader(file, hasher))
+	progress := newProgressReporter(filepath.Base(absPath), info.Size())
+
+	fileInfo := &BackupFileInfo{
+		OriginalPath: absPath,
+		Keyed:        opts.Encrypt,
+		Mode:         modeBits(info),
+		Sparse:       isSparse(info),
+		Meta:         captureMetadata(absPath, info),
+	}
+
+	for {
+		chunk, err := chunks.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			progress.Done()
+			return nil, fmt.Errorf(lang.Get("file_copy_error"), err)
+		}
+
+		object, err := bm.store.PutBytes(chunk, opts)
+		if err != nil {
+			progress.Done()
+			return nil, fmt.Errorf(lang.Get("file_copy_error"), err)
+		}
+
+		fileInfo.Chunks = append(fileInfo.Chunks, ChunkRef{Hash: object.Hash, Size: object.Size})
+		fileInfo.Size += object.Size
+		fileInfo.StoredSize += object.StoredSize
+		progress.Add(object.Size)
+	}
+	progress.Done()
+
+	fileInfo.Hash = hex.EncodeToString(hasher.Sum(nil))
+	return fileInfo, nil
+}
+
+// chunkReader, parçalı bir yedeği parçaları sırayla açarak tek parça gibi okur.
+type chunkReader struct {
+	store   *ObjectStore
+	chunks  []ChunkRef
+	current io.ReadCloser
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if cr.current == nil {
+			if len(cr.chunks) == 0 {
+				return 0, io.EOF
+			}
+			reader, err := cr.store.Open(cr.chunks[0].Hash)
+			if err != nil {
+				return 0, err
+			}
+			cr.current = reader
+			cr.chunks = cr.chunks[1:]
+		}
+
+		n, err := cr.current.Read(p)
+		if err == io.EOF {
+			cr.current.Close()
+			cr.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (cr *chunkReader) Close() error {
+	if cr.current != nil {
+		return cr.current.Close()
+	}
+	return nil
+}
+
+// progressReporter, uzun süren yedeklemelerde yüzdeyi stderr'e yazar.
+// Yüzde değişmedikçe satır yeniden yazılmaz.
+type progressReporter struct {
+	name    string
+	total   int64
+	done    int64
+	percent int
+}
+
+func newProgressReporter(name string, total int64) *progressReporter {
+	return &progressReporter{name: name, total: total, percent: -1}
+}
+
+func (p *progressReporter) Add(n int64) {
+	p.done += n
+	if p.total <= 0 {
+		return
+	}
+
+	percent := int(p.done * 100 / p.total)
+	if percent > 100 {
+		percent = 100
+	}
+	if percent != p.percent {
+		p.percent = percent
+		fmt.Fprintf(os.Stderr, "\r"+lang.Get("backup_progress"), p.name, percent, formatSize(p.done), formatSize(p.total))
+	}
+}
+
+func (p *progressReporter) Done() {
+	if p.percent >= 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// cloneFile, src içeriğini dst'ye en ucuz yoldan kopyalar: önce yazma
+// sırasında kopyalanan (reflink) bir klon denenir, bu sadece veri bloklarını
+// paylaştırır ve anında biter. Dosya sistemi desteklemiyorsa ya da dosyalar
+// farklı dosya sistemlerindeyse seyrek dosyalar boşlukları korunarak
+// kopyalanır, diğerleri için os.File.ReadFrom'a düşülür; o da Linux'ta
+// copy_file_range, olmazsa normal okuma/yazma kullanır. dst boş olmalıdır.
+func cloneFile(dst, src *os.File) (reflinked bool, err error) {
+	if err := reflink(dst, src); err == nil {
+		return true, nil
+	}
+
+	if info, err := src.Stat(); err == nil && isSparse(info) {
+		if err := sparseCopy(dst, src, info.Size()); err == nil {
+			return false, nil
+		}
+
+		// Yarıda kalan seyrek kopyayı at ve baştan normal kopyala
+		if err := resetFiles(dst, src); err != nil {
+			return false, err
+		}
+	}
+
+	_, err = io.Copy(dst, src)
+	return false, err
+}
+
+func resetFiles(dst, src *os.File) error {
+	if err := dst.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := dst.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := src.Seek(0, io.SeekStart)
+	return err
+}
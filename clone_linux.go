@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone, linux/fs.h içindeki FICLONE ioctl'ü (_IOW(0x94, 9, int)).
+// btrfs, XFS (reflink=1), bcachefs ve OCFS2 destekler.
+const ficlone = 0x40049409
+
+func reflink(dst, src *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
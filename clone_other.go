@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// Diğer sistemlerde reflink için syscall paketinde bir arayüz yoktur.
+func reflink(dst, src *os.File) error {
+	return errors.ErrUnsupported
+}
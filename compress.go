@@ -0,0 +1,92 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sysundo/lang"
+)
+
+// Desteklenen sıkıştırma biçimleri. Sadece standart kütüphanedeki
+// biçimler kullanılır, böylece harici bağımlılık gerekmez. zlib, gzip ile
+// aynı DEFLATE sıkıştırmasını farklı bir başlıkla kullandığından artık
+// seçilemez; eski sürümlerin yazdığı zlib nesneleri okunmaya devam eder.
+const (
+	codecNone = "none"
+	codecGzip = "gzip"
+	codecZlib = "zlib"
+)
+
+// codecSuffixes, nesne deposunda her biçimin dosya adı son eki
+var codecSuffixes = map[string]string{
+	codecNone: "",
+	codecGzip: ".gz",
+	codecZlib: ".zz",
+}
+
+// isValidCodec, yapılandırmada seçilebilecek (yazılabilen) biçimleri kabul eder.
+func isValidCodec(codec string) bool {
+	return codec == codecNone || codec == codecGzip
+}
+
+// normalizeCodec, eski kayıtlardaki boş biçimi "none" olarak yorumlar.
+func normalizeCodec(codec string) string {
+	if codec == "" {
+		return codecNone
+	}
+	return codec
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newCodecWriter, w'ye yazılanları verilen biçimde sıkıştıran bir yazıcı
+// döndürür. level 0 ise biçimin varsayılan seviyesi kullanılır.
+func newCodecWriter(codec string, w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	switch normalizeCodec(codec) {
+	case codecNone:
+		return nopWriteCloser{w}, nil
+	case codecGzip:
+		return gzip.NewWriterLevel(w, level)
+	}
+
+	return nil, fmt.Errorf(lang.Get("unknown_codec"), codec)
+}
+
+// newCodecReader, r'den okunanları verilen biçimden açan bir okuyucu döndürür.
+func newCodecReader(codec string, r io.Reader) (io.ReadCloser, error) {
+	switch normalizeCodec(codec) {
+	case codecNone:
+		return io.NopCloser(r), nil
+	case codecGzip:
+		return gzip.NewReader(r)
+	case codecZlib:
+		return zlib.NewReader(r)
+	}
+
+	return nil, fmt.Errorf(lang.Get("unknown_codec"), codec)
+}
+
+// stackedReadCloser, açıcı okuyucuyu ve altındaki dosyayı birlikte kapatır.
+type stackedReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (s *stackedReadCloser) Close() error {
+	var firstErr error
+	for _, closer := range s.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
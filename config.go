@@ -0,0 +1,461 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sysundo/internal/atomicfile"
+	"sysundo/lang"
+)
+
+// Config, yedekleme politikasını tanımlar. Değerler ~/.sysundo/config.json
+// dosyasından (dil ayarıyla aynı dosya) okunur; dosyada olmayan alanlar
+// varsayılan değerlerini korur.
+type Config struct {
+	MaxFileSize          int64                      `json:"max_file_size"`          // Bayt cinsinden boyut sınırı
+	ChunkedBackup        bool                       `json:"chunked_backup"`         // max_file_size'dan büyük dosyaları parçalara bölerek yedekle
+	MaxChunkedSize       int64                      `json:"max_chunked_size"`       // Parçalı yedeklenecek dosyaların boyut sınırı, 0 sınırsız
+	SupportedExts        []string                   `json:"supported_exts"`         // Desteklenen uzantılar
+	ExcludedExts         []string                   `json:"excluded_exts"`          // Hariç tutulan uzantılar
+	TextFilesOnly        bool                       `json:"text_files_only"`        // Sadece içeriği metin olan dosyalar (uzantıdan bağımsız)
+	Policy               string                     `json:"policy"`                 // "extensions" veya "smart" (uzantı listesi + içerik tespiti)
+	Compression          string                     `json:"compression"`            // Yedeklerin sıkıştırma biçimi: "gzip" veya "none"
+	CompressionLevel     int                        `json:"compression_level"`      // 1 (hızlı) - 9 (en iyi), 0 varsayılan
+	BackupSymlinkTargets bool                       `json:"backup_symlink_targets"` // Sembolik bağların işaret ettiği dosyaları da yedekle
+	Encryption           string                     `json:"encryption"`             // Yedeklerin şifrelenmesi: "none", "passphrase" veya "keyfile"
+	KeyFile              string                     `json:"key_file"`               // encryption "keyfile" ise anahtar dosyasının yolu
+	MaxAgeDays           int                        `json:"max_age_days"`           // Bu kadar günden eski işlemler budanır, 0 sınırsız
+	MaxTotalSize         int64                      `json:"max_total_size"`         // Deponun en fazla boyutu, 0 sınırsız
+	KeepLast             int                        `json:"keep_last"`              // Kurallardan bağımsız olarak korunan son işlem sayısı
+	AutoGC               bool                       `json:"auto_gc"`                // İzlenen her işlemden sonra (günde en fazla bir kez) gc çalıştır
+	Safety               string                     `json:"safety"`                 // Yedekleme hatasında davranış: "warn", "abort" veya "strict"
+	IncludePatterns      []string                   `json:"include_patterns"`       // Her zaman yedeklenecek glob desenleri
+	ExcludePatterns      []string                   `json:"exclude_patterns"`       // Asla yedeklenmeyecek glob desenleri
+	Directories          map[string]json.RawMessage `json:"directories,omitempty"`  // Dizin bazlı geçersiz kılmalar
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		MaxFileSize: 10 * 1024 * 1024, // 10MB
+		SupportedExts: []string{
+			".txt", ".md", ".json", ".yaml", ".yml",
+			".sh", ".js", ".py",
+		},
+		ExcludedExts:  []string{".mp4", ".zip", ".tar", ".gz"},
+		TextFilesOnly: false,
+		Policy:        policyExtensions,
+		Compression:   codecGzip,
+		Encryption:    encryptionNone,
+		AutoGC:        true,
+		Safety:        safetyWarn,
+	}
+}
+
+const (
+	policyExtensions = "extensions" // Sadece uzantı listesi
+	policySmart      = "smart"      // Listede olmayan uzantılar için içerik tespiti
+)
+
+const (
+	safetyWarn   = "warn"   // Yedeklenemeyen dosyalar için uyar, komutu yine de çalıştır
+	safetyAbort  = "abort"  // Yedekleme veya kayıt hatasında komutu çalıştırma
+	safetyStrict = "strict" // Ayrıca politika gereği yedeklenmeyecek dosya varsa da çalıştırma
+)
+
+// validate, serbest metin alanlarının geçerli değerler içerdiğini kontrol eder.
+func (c *Config) validate() error {
+	if c.Policy != policyExtensions && c.Policy != policySmart {
+		return fmt.Errorf(lang.Get("config_invalid_value"), c.Policy, "policy")
+	}
+	if !isValidCodec(c.Compression) {
+		return fmt.Errorf(lang.Get("config_invalid_value"), c.Compression, "compression")
+	}
+	if c.Encryption != encryptionNone && c.Encryption != encryptionPassphrase && c.Encryption != encryptionKeyFile {
+		return fmt.Errorf(lang.Get("config_invalid_value"), c.Encryption, "encryption")
+	}
+	if c.Safety != safetyWarn && c.Safety != safetyAbort && c.Safety != safetyStrict {
+		return fmt.Errorf(lang.Get("config_invalid_value"), c.Safety, "safety")
+	}
+	if c.MaxAgeDays < 0 {
+		return fmt.Errorf(lang.Get("config_invalid_value"), strconv.Itoa(c.MaxAgeDays), "max_age_days")
+	}
+	if c.MaxChunkedSize < 0 {
+		return fmt.Errorf(lang.Get("config_invalid_value"), strconv.FormatInt(c.MaxChunkedSize, 10), "max_chunked_size")
+	}
+	if c.KeepLast < 0 {
+		return fmt.Errorf(lang.Get("config_invalid_value"), strconv.Itoa(c.KeepLast), "keep_last")
+	}
+	if c.CompressionLevel < 0 || c.CompressionLevel > 9 {
+		return fmt.Errorf(lang.Get("config_invalid_value"), strconv.Itoa(c.CompressionLevel), "compression_level")
+	}
+	return nil
+}
+
+func configPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".sysundo", "config.json")
+}
+
+// LoadConfig varsayılanların üzerine config.json içeriğini uygular.
+func LoadConfig() (*Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(configPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, fmt.Errorf(lang.Get("config_read_error"), err)
+	}
+
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return DefaultConfig(), fmt.Errorf(lang.Get("config_read_error"), err)
+	}
+
+	// Elle düzenlenmiş dosyadaki geçersiz değerler (genel veya dizine özel)
+	// yedeklemeler sırasında değil, burada yakalanır
+	config.normalize()
+	if err := config.validate(); err != nil {
+		return DefaultConfig(), err
+	}
+	for dir := range config.Directories {
+		if _, err := config.forPath(expandHome(dir)); err != nil {
+			return DefaultConfig(), fmt.Errorf(lang.Get("config_override_invalid"), dir, err)
+		}
+	}
+
+	return config, nil
+}
+
+// ForPath, path için geçerli politikayı döndürür. path'i kapsayan dizin
+// geçersiz kılmaları, en genelden en özele doğru sırayla uygulanır.
+// Geçersiz bir geçersiz kılma uyarıyla yok sayılır.
+func (c *Config) ForPath(path string) *Config {
+	effective, err := c.forPath(path)
+	if err != nil {
+		fmt.Printf(lang.Get("config_override_warning")+"\n", path, err)
+		return c
+	}
+	return effective
+}
+
+// forPath, ForPath'in birleştirilmiş yapılandırmayı doğrulayıp hatayı
+// döndüren biçimi.
+func (c *Config) forPath(path string) (*Config, error) {
+	var dirs []string
+	for dir := range c.Directories {
+		if hasPathPrefix(path, expandHome(dir)) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	if len(dirs) == 0 {
+		return c, nil
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(expandHome(dirs[i])) < len(expandHome(dirs[j]))
+	})
+
+	// Dilimler paylaşılmasın diye JSON üzerinden derin kopya al
+	effective := &Config{}
+	data, err := json.Marshal(c)
+	if err == nil {
+		err = json.Unmarshal(data, effective)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		if err := json.Unmarshal(c.Directories[dir], effective); err != nil {
+			return nil, err
+		}
+	}
+	effective.Directories = nil
+	effective.normalize()
+
+	if err := effective.validate(); err != nil {
+		return nil, err
+	}
+
+	return effective, nil
+}
+
+// encryptsAny, şifrelemenin genel olarak ya da herhangi bir dizin için açık
+// olup olmadığını bildirir.
+func (c *Config) encryptsAny() bool {
+	if c.Encryption != encryptionNone {
+		return true
+	}
+	for dir := range c.Directories {
+		if c.ForPath(expandHome(dir)).Encryption != encryptionNone {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize uzantıları küçük harfe çevirir ve başlarına nokta ekler.
+func (c *Config) normalize() {
+	c.SupportedExts = normalizeExts(c.SupportedExts)
+	c.ExcludedExts = normalizeExts(c.ExcludedExts)
+
+	// Eski yapılandırmalardaki zlib aynı sıkıştırmayı yapan gzip'e çevrilir
+	if c.Compression == codecZlib {
+		c.Compression = codecGzip
+	}
+}
+
+func normalizeExts(exts []string) []string {
+	normalized := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
+}
+
+// matchesAnyPattern, path'in tam yolunun veya dosya adının desenlerden
+// biriyle eşleşip eşleşmediğini kontrol eder.
+func matchesAnyPattern(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = expandHome(pattern)
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
+
+// configField, JSON anahtarına karşılık gelen Config alanını bulur.
+func configField(key string) (reflect.StructField, bool) {
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == key && key != "directories" {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// ConfigKeys ayarlanabilir tüm anahtarları döndürür.
+func ConfigKeys() []string {
+	var keys []string
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		name := strings.Split(configType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "directories" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// parseConfigValue komut satırından gelen değeri anahtarın türüne göre
+// JSON'a çevirir. Listeler virgülle ayrılır, boyutlar KB/MB/GB alabilir.
+func parseConfigValue(key, value string) (json.RawMessage, error) {
+	field, ok := configField(key)
+	if !ok {
+		return nil, fmt.Errorf(lang.Get("config_unknown_key"), key)
+	}
+
+	var parsed interface{}
+	switch field.Type.Kind() {
+	case reflect.Int64:
+		size, err := parseSize(value)
+		if err != nil {
+			return nil, fmt.Errorf(lang.Get("config_invalid_value"), value, key)
+		}
+		parsed = size
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf(lang.Get("config_invalid_value"), value, key)
+		}
+		parsed = n
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf(lang.Get("config_invalid_value"), value, key)
+		}
+		parsed = b
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		parsed = items
+	default:
+		parsed = value
+	}
+
+	return json.Marshal(parsed)
+}
+
+// parseSize "10MB", "512K" veya bayt sayısı biçimindeki boyutları çözer.
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.TrimSuffix(value, "B")
+
+	multiplier := int64(1)
+	units := map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+	if len(value) > 0 {
+		if m, ok := units[value[len(value)-1:]]; ok {
+			multiplier = m
+			value = value[:len(value)-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf(lang.Get("config_invalid_value"), value, "size")
+	}
+
+	return int64(n * float64(multiplier)), nil
+}
+
+// readConfigFile config.json'ı ham anahtar/değer olarak okur, böylece dil
+// ayarı gibi bu paketin bilmediği anahtarlar yazarken korunur.
+func readConfigFile() (map[string]json.RawMessage, error) {
+	raw := make(map[string]json.RawMessage)
+
+	data, err := os.ReadFile(configPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return raw, nil
+		}
+		return nil, fmt.Errorf(lang.Get("config_read_error"), err)
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf(lang.Get("config_read_error"), err)
+	}
+
+	return raw, nil
+}
+
+func writeConfigFile(raw map[string]json.RawMessage) error {
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return fmt.Errorf(lang.Get("json_marshal_error"), err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath()), 0755); err != nil {
+		return fmt.Errorf(lang.Get("config_write_error"), err)
+	}
+
+	if err := atomicfile.WriteFile(configPath(), data, 0644); err != nil {
+		return fmt.Errorf(lang.Get("config_write_error"), err)
+	}
+
+	return nil
+}
+
+// SetConfigValue bir anahtarı genel olarak ya da dir verilmişse sadece o
+// dizin (ve altı) için ayarlar.
+func SetConfigValue(key, value, dir string) error {
+	encoded, err := parseConfigValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	raw, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if dir == "" {
+		raw[key] = encoded
+	} else {
+		directories := make(map[string]map[string]json.RawMessage)
+		if existing, ok := raw["directories"]; ok {
+			if err := json.Unmarshal(existing, &directories); err != nil {
+				return fmt.Errorf(lang.Get("config_read_error"), err)
+			}
+		}
+
+		if directories[dir] == nil {
+			directories[dir] = make(map[string]json.RawMessage)
+		}
+		directories[dir][key] = encoded
+
+		raw["directories"], err = json.Marshal(directories)
+		if err != nil {
+			return fmt.Errorf(lang.Get("json_marshal_error"), err)
+		}
+	}
+
+	// Yazmadan önce ortaya çıkan yapılandırmanın okunabildiğini doğrula
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf(lang.Get("json_marshal_error"), err)
+	}
+	check := DefaultConfig()
+	if err := json.Unmarshal(data, check); err != nil {
+		return fmt.Errorf(lang.Get("config_invalid_value"), value, key)
+	}
+	if err := check.validate(); err != nil {
+		return err
+	}
+	if _, err := check.forPath(dir); err != nil {
+		return err
+	}
+
+	return writeConfigFile(raw)
+}
+
+// GetConfigValue anahtarın path için geçerli değerini JSON olarak döndürür.
+func GetConfigValue(config *Config, key, path string) (string, error) {
+	field, ok := configField(key)
+	if !ok {
+		return "", fmt.Errorf(lang.Get("config_unknown_key"), key)
+	}
+
+	effective := config
+	if path != "" {
+		effective = config.ForPath(path)
+	}
+
+	value := reflect.ValueOf(effective).Elem().FieldByIndex(field.Index).Interface()
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf(lang.Get("json_marshal_error"), err)
+	}
+
+	return string(data), nil
+}
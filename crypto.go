@@ -0,0 +1,751 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sysundo/internal/atomicfile"
+	"sysundo/lang"
+)
+
+// Şifreleme kaynakları
+const (
+	encryptionNone       = "none"
+	encryptionPassphrase = "passphrase"
+	encryptionKeyFile    = "keyfile"
+)
+
+const (
+	encryptedSuffix   = ".enc"
+	encMagic          = "SYSUNDO\x01"
+	encKeyIDSize      = 8
+	encNoncePrefix    = 7
+	encSegmentSize    = 64 * 1024
+	pbkdf2Iterations  = 210000
+	keyCheckMessage   = "sysundo-key-check"
+	nameKeyLabel      = "sysundo-name-key"
+	passphraseEnv     = "SYSUNDO_PASSPHRASE"
+	newPassphraseEnv  = "SYSUNDO_NEW_PASSPHRASE"
+	encryptionKeySize = 32
+)
+
+// keyInfo, bir şifreleme anahtarının nasıl türetileceğini tanımlar. Anahtarın
+// kendisi asla diske yazılmaz; Check alanı girilen parolanın doğruluğunu
+// kontrol etmek için kullanılır.
+type keyInfo struct {
+	ID         string `json:"id"`
+	Source     string `json:"source"`
+	KeyFile    string `json:"key_file,omitempty"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations,omitempty"`
+	Check      []byte `json:"check"`
+}
+
+// keyring, ~/.sysundo/keyring.json dosyasının içeriği. Anahtar döndürme
+// sırasında eski anahtar, bütün nesneler yeni anahtara geçene kadar listede kalır.
+type keyring struct {
+	Current   string    `json:"current"`
+	Keys      []keyInfo `json:"keys"`
+	NameKey   []byte    `json:"name_key,omitempty"`    // Nesne adlarının HMAC anahtarı, NameKeyID anahtarıyla şifreli
+	NameKeyID string    `json:"name_key_id,omitempty"` // NameKey'i şifreleyen anahtar
+}
+
+// KeyManager, şifreleme anahtarlarını gerektiğinde (parola sorarak veya
+// anahtar dosyasını okuyarak) türetir ve oturum boyunca önbellekte tutar.
+type KeyManager struct {
+	path    string
+	cache   map[string][]byte
+	nameKey []byte
+}
+
+func NewKeyManager(path string) *KeyManager {
+	return &KeyManager{
+		path:  path,
+		cache: make(map[string][]byte),
+	}
+}
+
+func (km *KeyManager) load() (*keyring, error) {
+	data, err := os.ReadFile(km.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf(lang.Get("keyring_not_initialized"))
+		}
+		return nil, fmt.Errorf(lang.Get("keyring_read_error"), err)
+	}
+
+	var ring keyring
+	if err := json.Unmarshal(data, &ring); err != nil {
+		return nil, fmt.Errorf(lang.Get("keyring_read_error"), err)
+	}
+
+	return &ring, nil
+}
+
+func (km *KeyManager) save(ring *keyring) error {
+	data, err := json.MarshalIndent(ring, "", "  ")
+	if err != nil {
+		return fmt.Errorf(lang.Get("json_marshal_error"), err)
+	}
+
+	if err := atomicfile.WriteFile(km.path, data, 0600); err != nil {
+		return fmt.Errorf(lang.Get("keyring_write_error"), err)
+	}
+
+	return nil
+}
+
+// CurrentKey, yeni nesnelerin şifreleneceği anahtarı döndürür.
+func (km *KeyManager) CurrentKey() (string, []byte, error) {
+	ring, err := km.load()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := km.Key(ring.Current)
+	return ring.Current, key, err
+}
+
+// Key, ID'si verilen anahtarı türetir.
+func (km *KeyManager) Key(id string) ([]byte, error) {
+	if key, ok := km.cache[id]; ok {
+		return key, nil
+	}
+
+	ring, err := km.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range ring.Keys {
+		if info.ID != id {
+			continue
+		}
+
+		secret, err := readKeySecret(info, passphraseEnv, false)
+		if err != nil {
+			return nil, err
+		}
+
+		key := deriveKey(info, secret)
+		if !hmac.Equal(keyCheck(key), info.Check) {
+			return nil, fmt.Errorf(lang.Get("wrong_passphrase"), id)
+		}
+
+		km.cache[id] = key
+		return key, nil
+	}
+
+	return nil, fmt.Errorf(lang.Get("key_not_found"), id)
+}
+
+// NameKey, şifreli nesnelerin adlarını ve kayıtlardaki özetlerini üreten
+// HMAC anahtarını döndürür. Anahtar rastgeledir ve keyring'de şifreleme
+// anahtarıyla şifrelenmiş olarak durur; anahtar döndürmede yeni anahtarla
+// yeniden şifrelenir, böylece nesne adları değişmez. Bu alandan önce
+// oluşturulmuş keyring'lerde ilk kullanımda üretilir.
+func (km *KeyManager) NameKey() ([]byte, error) {
+	if km.nameKey != nil {
+		return km.nameKey, nil
+	}
+
+	ring, err := km.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ring.NameKey) == 0 {
+		key, err := km.Key(ring.Current)
+		if err != nil {
+			return nil, err
+		}
+
+		nameKey := make([]byte, encryptionKeySize)
+		if _, err := rand.Read(nameKey); err != nil {
+			return nil, err
+		}
+		if ring.NameKey, err = sealNameKey(key, nameKey); err != nil {
+			return nil, err
+		}
+		ring.NameKeyID = ring.Current
+		if err := km.save(ring); err != nil {
+			return nil, err
+		}
+
+		km.nameKey = nameKey
+		return nameKey, nil
+	}
+
+	key, err := km.Key(ring.NameKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	nameKey, err := openNameKey(key, ring.NameKey)
+	if err != nil {
+		return nil, err
+	}
+
+	km.nameKey = nameKey
+	return nameKey, nil
+}
+
+// sealNameKey, ad anahtarını bir şifreleme anahtarıyla AES-256-GCM
+// kullanarak şifreler (nonce | şifreli metin).
+func sealNameKey(key, nameKey []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, nameKey, []byte(nameKeyLabel)), nil
+}
+
+func openNameKey(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf(lang.Get("keyring_read_error"), lang.Get("invalid_encrypted_object"))
+	}
+
+	nameKey, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(nameKeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf(lang.Get("keyring_read_error"), lang.Get("invalid_encrypted_object"))
+	}
+
+	return nameKey, nil
+}
+
+// Init, yapılandırmadaki kaynaktan (parola veya anahtar dosyası) ilk anahtarı
+// ve nesne adlarının anahtarını oluşturur.
+func (km *KeyManager) Init(config *Config) error {
+	if _, err := os.Stat(km.path); err == nil {
+		return fmt.Errorf(lang.Get("keyring_exists"))
+	}
+
+	info, key, err := km.newKey(config, passphraseEnv)
+	if err != nil {
+		return err
+	}
+
+	nameKey := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(nameKey); err != nil {
+		return err
+	}
+	sealed, err := sealNameKey(key, nameKey)
+	if err != nil {
+		return err
+	}
+
+	km.cache[info.ID] = key
+	km.nameKey = nameKey
+	return km.save(&keyring{Current: info.ID, Keys: []keyInfo{*info}, NameKey: sealed, NameKeyID: info.ID})
+}
+
+// Rotate, yeni bir anahtar oluşturur, ad anahtarını yeni anahtarla yeniden
+// şifreler, reencrypt ile depodaki tüm nesneleri (şifrelenmemiş olanlar
+// dahil) yeni anahtara geçirir ve eski anahtarları siler. Yarıda kalırsa
+// eski anahtarlar keyring'de kalır ve işlem tekrar çalıştırılabilir.
+func (km *KeyManager) Rotate(config *Config, reencrypt func() (int, error)) (int, error) {
+	nameKey, err := km.NameKey()
+	if err != nil {
+		return 0, err
+	}
+
+	ring, err := km.load()
+	if err != nil {
+		return 0, err
+	}
+
+	// Mevcut anahtarları yeni anahtar eklenmeden önce türet
+	for _, info := range ring.Keys {
+		if _, err := km.Key(info.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	info, key, err := km.newKey(config, newPassphraseEnv)
+	if err != nil {
+		return 0, err
+	}
+	km.cache[info.ID] = key
+
+	if ring.NameKey, err = sealNameKey(key, nameKey); err != nil {
+		return 0, err
+	}
+	ring.NameKeyID = info.ID
+	ring.Keys = append(ring.Keys, *info)
+	ring.Current = info.ID
+	if err := km.save(ring); err != nil {
+		return 0, err
+	}
+
+	count, err := reencrypt()
+	if err != nil {
+		return count, err
+	}
+
+	ring.Keys = []keyInfo{*info}
+	return count, km.save(ring)
+}
+
+// reencryptStore, Rotate için depoyu geçerli anahtarla yeniden şifreler.
+// Düz özetle adlandırılmış nesneler anahtarlı adlarına taşındıysa geçmişteki
+// özetler de güncellenir; eski nesneler ancak geçmiş yeniden yazıldıktan
+// sonra silinir, böylece yarıda kalan bir döndürme hiçbir kaydı bozmaz.
+func (bm *BackupManager) reencryptStore() (int, error) {
+	count, renamed, stale, err := bm.store.Reencrypt()
+	if len(renamed) == 0 {
+		return count, err
+	}
+
+	if rekeyErr := bm.rekeyHistory(renamed); rekeyErr != nil {
+		return count, rekeyErr
+	}
+	for _, object := range stale {
+		bm.store.Remove(object)
+	}
+
+	return count, err
+}
+
+// rekeyHistory, kayıtlardaki düz özetleri renamed eşleşmelerine göre
+// anahtarlı özetlerle değiştirir. Parçalı yedeklerde dosyanın tamamının
+// özeti içerik okunarak yeniden hesaplanır. Karşılığı olmayan cp özetleri
+// silinir; bu dosyalar geri almada uyarıyla korunur.
+func (bm *BackupManager) rekeyHistory(renamed map[string]string) error {
+	records, err := bm.history.Load()
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		record := &records[i]
+		for j := range record.Files {
+			fileInfo := &record.Files[j]
+			if fileInfo.Keyed || fileInfo.Hash == "" {
+				continue
+			}
+
+			if len(fileInfo.Chunks) == 0 {
+				if hash, ok := renamed[fileInfo.Hash]; ok {
+					fileInfo.Hash = hash
+					fileInfo.Keyed = true
+				}
+				continue
+			}
+
+			for k := range fileInfo.Chunks {
+				if hash, ok := renamed[fileInfo.Chunks[k].Hash]; ok {
+					fileInfo.Chunks[k].Hash = hash
+				}
+			}
+			if hash, err := bm.keyedContentHash(*fileInfo); err == nil {
+				fileInfo.Hash = hash
+				fileInfo.Keyed = true
+			}
+		}
+
+		effects := record.Effects
+		if effects == nil || effects.CreatedKeyed || len(effects.CreatedHashes) == 0 {
+			continue
+		}
+		for path, hash := range effects.CreatedHashes {
+			if keyed, ok := renamed[hash]; ok {
+				effects.CreatedHashes[path] = keyed
+			} else {
+				delete(effects.CreatedHashes, path)
+			}
+		}
+		effects.CreatedKeyed = true
+	}
+
+	return bm.history.Rewrite(records)
+}
+
+// keyedContentHash, düz özetli bir yedeğin içeriğini okuyarak anahtarlı
+// özetini hesaplar. İçerik kayıttaki düz özetle eşleşmiyorsa hata döner.
+func (bm *BackupManager) keyedContentHash(fileInfo BackupFileInfo) (string, error) {
+	backup, err := bm.OpenBackup(fileInfo)
+	if err != nil {
+		return "", err
+	}
+	defer backup.Close()
+
+	keyed, err := bm.store.newHasher(true)
+	if err != nil {
+		return "", err
+	}
+	plain := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(keyed, plain), backup); err != nil {
+		return "", err
+	}
+
+	if hex.EncodeToString(plain.Sum(nil)) != fileInfo.Hash {
+		return "", fmt.Errorf(lang.Get("object_hash_mismatch"), fileInfo.Hash)
+	}
+
+	return hex.EncodeToString(keyed.Sum(nil)), nil
+}
+
+func (km *KeyManager) newKey(config *Config, envName string) (*keyInfo, []byte, error) {
+	if config.Encryption != encryptionPassphrase && config.Encryption != encryptionKeyFile {
+		return nil, nil, fmt.Errorf(lang.Get("encryption_disabled"))
+	}
+
+	info := &keyInfo{
+		Source:  config.Encryption,
+		KeyFile: expandHome(config.KeyFile),
+		Salt:    make([]byte, 16),
+	}
+	if info.Source == encryptionPassphrase {
+		info.KeyFile = ""
+		info.Iterations = pbkdf2Iterations
+	}
+
+	if _, err := rand.Read(info.Salt); err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := readKeySecret(*info, envName, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := deriveKey(*info, secret)
+	info.Check = keyCheck(key)
+
+	idSum := sha256.Sum256(append([]byte("sysundo-key-id"), key...))
+	info.ID = hex.EncodeToString(idSum[:encKeyIDSize])
+
+	return info, key, nil
+}
+
+// readKeySecret, anahtarın kaynağına göre parolayı ya da anahtar dosyasının
+// içeriğini okur. Parola önce ortam değişkeninden, yoksa terminalden alınır.
+func readKeySecret(info keyInfo, envName string, confirm bool) ([]byte, error) {
+	if info.Source == encryptionKeyFile {
+		if info.KeyFile == "" {
+			return nil, fmt.Errorf(lang.Get("key_file_not_set"))
+		}
+		data, err := os.ReadFile(info.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf(lang.Get("key_file_read_error"), err)
+		}
+		return data, nil
+	}
+
+	if value := os.Getenv(envName); value != "" {
+		return []byte(value), nil
+	}
+
+	prompt := fmt.Sprintf(lang.Get("passphrase_prompt"), info.ID)
+	if confirm {
+		prompt = lang.Get("new_passphrase_prompt")
+	}
+
+	passphrase, err := readPassphrase(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	if confirm {
+		again, err := readPassphrase(lang.Get("confirm_passphrase_prompt"))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, fmt.Errorf(lang.Get("passphrase_mismatch"))
+		}
+	}
+
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf(lang.Get("passphrase_empty"))
+	}
+
+	return passphrase, nil
+}
+
+// readPassphrase, terminalden yankı kapalıyken bir satır okur. Terminal
+// yoksa (veya yankı kapatılamıyorsa) standart girdiden okunur.
+func readPassphrase(prompt string) ([]byte, error) {
+	input := os.Stdin
+	if runtime.GOOS != "windows" {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			defer tty.Close()
+			input = tty
+		}
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	if setTerminalEcho(input, false) {
+		defer func() {
+			setTerminalEcho(input, true)
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+
+	line, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, fmt.Errorf(lang.Get("passphrase_read_error"), err)
+	}
+
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+func setTerminalEcho(tty *os.File, on bool) bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = tty
+	return cmd.Run() == nil
+}
+
+func deriveKey(info keyInfo, secret []byte) []byte {
+	if info.Source == encryptionKeyFile {
+		mac := hmac.New(sha256.New, info.Salt)
+		mac.Write(secret)
+		return mac.Sum(nil)
+	}
+	return pbkdf2SHA256(secret, info.Salt, info.Iterations, encryptionKeySize)
+}
+
+func keyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(keyCheckMessage))
+	return mac.Sum(nil)
+}
+
+// pbkdf2SHA256, RFC 8018 PBKDF2 fonksiyonunun HMAC-SHA256 ile uygulaması.
+// Standart kütüphanedeki crypto/pbkdf2 go.mod'daki sürümde bulunmadığı için
+// burada tanımlanır.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	var key []byte
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for n := 1; n < iterations; n++ {
+			u = hmacSum(prf, u)
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		key = append(key, t...)
+	}
+
+	return key[:keyLen]
+}
+
+func hmacSum(prf hash.Hash, data []byte) []byte {
+	prf.Reset()
+	prf.Write(data)
+	return prf.Sum(nil)
+}
+
+// Şifreli nesne biçimi:
+//
+//	başlık:  encMagic | anahtar ID (8 bayt) | nonce öneki (7 bayt)
+//	bölüm:   AES-256-GCM(64 KB düz metin), nonce = önek | sıra (4 bayt) | son bölüm bayrağı
+//
+// Başlık her bölümde ek doğrulanmış veri olarak kullanılır. Son bölüm
+// bayrağı sayesinde sondan kesilmiş bir nesne hata olarak algılanır.
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+}
+
+func newEncryptWriter(w io.Writer, keyID string, key []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := hex.DecodeString(keyID)
+	if err != nil || len(id) != encKeyIDSize {
+		return nil, fmt.Errorf(lang.Get("key_not_found"), keyID)
+	}
+
+	prefix := make([]byte, encNoncePrefix)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	header := append(append([]byte(encMagic), id...), prefix...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		buf:    make([]byte, 0, encSegmentSize),
+	}, nil
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// Tam bir bölümü ancak arkasından veri geldiğinde yaz, son bölüm Close'da yazılır
+		if len(ew.buf) == encSegmentSize {
+			if err := ew.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(ew.buf[len(ew.buf):encSegmentSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (ew *encryptWriter) Close() error {
+	return ew.seal(true)
+}
+
+func (ew *encryptWriter) seal(final bool) error {
+	sealed := ew.aead.Seal(nil, segmentNonce(ew.prefix, ew.counter, final), ew.buf, ew.header)
+	ew.counter++
+	ew.buf = ew.buf[:0]
+	_, err := ew.w.Write(sealed)
+	return err
+}
+
+func segmentNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 0, encNoncePrefix+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+	plain   []byte
+	done    bool
+}
+
+// newDecryptReader, şifreli bir nesneyi başlığındaki anahtar ID'sine göre
+// doğru anahtarı bularak çözen bir okuyucu döndürür.
+func newDecryptReader(r io.Reader, keys *KeyManager) (io.Reader, error) {
+	header := make([]byte, len(encMagic)+encKeyIDSize+encNoncePrefix)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.HasPrefix(header, []byte(encMagic)) {
+		return nil, fmt.Errorf(lang.Get("invalid_encrypted_object"))
+	}
+
+	keyID := hex.EncodeToString(header[len(encMagic) : len(encMagic)+encKeyIDSize])
+	key, err := keys.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:      bufio.NewReaderSize(r, encSegmentSize+aead.Overhead()+1),
+		aead:   aead,
+		header: header,
+		prefix: header[len(encMagic)+encKeyIDSize:],
+	}, nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.plain) == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		if err := dr.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return n, nil
+}
+
+func (dr *decryptReader) open() error {
+	sealed := make([]byte, encSegmentSize+dr.aead.Overhead())
+	n, err := io.ReadFull(dr.r, sealed)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf(lang.Get("invalid_encrypted_object"))
+	}
+
+	// Arkasından veri gelmiyorsa bu son bölümdür
+	_, peekErr := dr.r.Peek(1)
+	final := peekErr == io.EOF
+
+	plain, err := dr.aead.Open(nil, segmentNonce(dr.prefix, dr.counter, final), sealed[:n], dr.header)
+	if err != nil {
+		return fmt.Errorf(lang.Get("invalid_encrypted_object"))
+	}
+
+	dr.counter++
+	dr.plain = plain
+	dr.done = final
+	return nil
+}
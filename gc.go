@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sysundo/lang"
+	"time"
+)
+
+// gcGracePeriod, yeni yazılmış nesnelerin silinmeden önce beklemesi gereken
+// süre. Bir watch işlemi nesneyi depoya ekledikten sonra kaydını geçmişe
+// eklemeden önce çalışan bir gc, o nesneyi sahipsiz sanmamalıdır.
+const gcGracePeriod = time.Hour
+
+// autoGCInterval, otomatik gc'nin en fazla hangi sıklıkta çalışacağı
+const autoGCInterval = 24 * time.Hour
+
+// GCResult, bir çöp toplama çalışmasının sonucunu özetler.
+type GCResult struct {
+	RemovedRecords []BackupRecord // Saklama kurallarına göre budanan işlemler
+	RemovedObjects int            // Silinen sahipsiz nesne ve eski cache dosyası sayısı
+	FreedSize      int64          // Serbest kalan disk alanı
+	KeptRecords    int
+}
+
+// GarbageCollect saklama kurallarını uygular: süresi dolan işlemleri
+// geçmişten çıkarır, ardından hiçbir kaydın kullanmadığı nesneleri ve eski
+// cache dosyalarını siler. dryRun ise hiçbir şey değiştirilmez, sadece
+// silinecekler hesaplanır.
+func (bm *BackupManager) GarbageCollect(config *Config, dryRun bool) (*GCResult, error) {
+	lock, err := bm.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	records, err := bm.history.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := bm.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := retainedRecords(records, objects, config, time.Now())
+
+	result := &GCResult{}
+	var kept []BackupRecord
+	for i, record := range records {
+		if keep[i] {
+			kept = append(kept, record)
+		} else {
+			result.RemovedRecords = append(result.RemovedRecords, record)
+		}
+	}
+	result.KeptRecords = len(kept)
+
+	if !dryRun && len(result.RemovedRecords) > 0 {
+		if err := bm.history.Rewrite(kept); err != nil {
+			return nil, err
+		}
+	}
+
+	// Geçmiş yazıldıktan sonra sahipsiz kalan nesneleri sil
+	refs := bm.store.References(kept)
+	cutoff := time.Now().Add(-gcGracePeriod)
+	for _, object := range objects {
+		if refs[object.Hash] > 0 || object.ModTime.After(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := bm.store.Remove(object); err != nil {
+				continue
+			}
+		}
+		result.RemovedObjects++
+		result.FreedSize += object.StoredSize
+	}
+
+	removed, freed := bm.removeLegacyBlobs(kept, cutoff, dryRun)
+	result.RemovedObjects += removed
+	result.FreedSize += freed
+
+	if !dryRun {
+		bm.touchGCStamp()
+	}
+
+	return result, nil
+}
+
+// retainedRecords hangi kayıtların tutulacağını belirler. Sabitlenmiş
+// işlemler ve son keep_last işlem (keep_last 0 olsa bile en az en son işlem;
+// otomatik gc izlenen komutun hemen ardından çalışır ve onun kaydını
+// silmemelidir) her zaman tutulur. Diğerleri önce yaşa göre, sonra depo
+// max_total_size altına inene kadar en eskiden başlanarak budanır.
+func retainedRecords(records []BackupRecord, objects []StoredObject, config *Config, now time.Time) []bool {
+	keepLast := config.KeepLast
+	if keepLast < 1 {
+		keepLast = 1
+	}
+
+	keep := make([]bool, len(records))
+	protected := make([]bool, len(records))
+	for i, record := range records {
+		keep[i] = true
+		protected[i] = record.Pinned || i >= len(records)-keepLast
+
+		if !protected[i] && config.MaxAgeDays > 0 {
+			maxAge := time.Duration(config.MaxAgeDays) * 24 * time.Hour
+			keep[i] = now.Sub(record.Timestamp) <= maxAge
+		}
+	}
+
+	if config.MaxTotalSize <= 0 {
+		return keep
+	}
+
+	storedSizes := make(map[string]int64)
+	for _, object := range objects {
+		storedSizes[object.Hash] = object.StoredSize
+	}
+
+	refs := make(map[string]int)
+	var total int64
+	for i, record := range records {
+		if !keep[i] {
+			continue
+		}
+		for _, hash := range recordHashes(record) {
+			if refs[hash] == 0 {
+				total += storedSizes[hash]
+			}
+			refs[hash]++
+		}
+	}
+
+	for i := 0; i < len(records) && total > config.MaxTotalSize; i++ {
+		if !keep[i] || protected[i] {
+			continue
+		}
+		keep[i] = false
+		for _, hash := range recordHashes(records[i]) {
+			refs[hash]--
+			if refs[hash] == 0 {
+				total -= storedSizes[hash]
+			}
+		}
+	}
+
+	return keep
+}
+
+// recordHashes, bir kaydın kullandığı benzersiz nesne özetlerini döndürür.
+func recordHashes(record BackupRecord) []string {
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, fileInfo := range record.Files {
+		for _, hash := range fileInfo.ObjectHashes() {
+			if !seen[hash] {
+				seen[hash] = true
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	return hashes
+}
+
+// removeLegacyBlobs, eski sürümlerin cache dizininde kalan ve hiçbir kaydın
+// kullanmadığı yedek dosyalarını siler.
+func (bm *BackupManager) removeLegacyBlobs(kept []BackupRecord, cutoff time.Time, dryRun bool) (int, int64) {
+	used := make(map[string]bool)
+	for _, record := range kept {
+		for _, fileInfo := range record.Files {
+			if fileInfo.BackupPath != "" {
+				used[filepath.Clean(fileInfo.BackupPath)] = true
+			}
+		}
+	}
+
+	removed := 0
+	var freed int64
+	filepath.WalkDir(bm.backupDir, func(path string, d fs.DirEntry, err error) error {
+		// Taşınamamış eski kayıt dosyası bir yedek değildir, silinmez
+		if err != nil || d.IsDir() || d.Name() == "last_backup.json" || used[filepath.Clean(path)] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				return nil
+			}
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+
+	return removed, freed
+}
+
+func (bm *BackupManager) gcStampPath() string {
+	return filepath.Join(bm.baseDir, "gc.stamp")
+}
+
+func (bm *BackupManager) touchGCStamp() {
+	now := time.Now()
+	if err := os.Chtimes(bm.gcStampPath(), now, now); err != nil {
+		os.WriteFile(bm.gcStampPath(), nil, 0644)
+	}
+}
+
+// AutoGC, auto_gc açıksa ve son çalışmadan bu yana yeterli süre geçtiyse
+// gc'yi çalıştırır. Hatalar sadece uyarı olarak gösterilir; yedekleme
+// işlemini asla engellemez.
+func (bm *BackupManager) AutoGC(config *Config) {
+	if !config.AutoGC {
+		return
+	}
+
+	if info, err := os.Stat(bm.gcStampPath()); err == nil && time.Since(info.ModTime()) < autoGCInterval {
+		return
+	}
+
+	result, err := bm.GarbageCollect(config, false)
+	if err != nil {
+		fmt.Printf(lang.Get("auto_gc_warning")+"\n", err)
+		return
+	}
+
+	if len(result.RemovedRecords) > 0 || result.RemovedObjects > 0 {
+		printGCSummary(result, false)
+	}
+}
+
+// SetPinned bir işlemi sabitler veya sabitlemesini kaldırır.
+func (bm *BackupManager) SetPinned(id string, pinned bool) (*BackupRecord, error) {
+	lock, err := bm.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	target, err := bm.history.Find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := bm.history.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		if records[i].ID == target.ID {
+			records[i].Pinned = pinned
+			target = &records[i]
+		}
+	}
+
+	if err := bm.history.Rewrite(records); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sysundo/internal/atomicfile"
+	"sysundo/lang"
+	"time"
+)
+
+// History, yedekleme kayıtlarını satır başına bir JSON kaydı olacak şekilde
+// ~/.sysundo/history.jsonl dosyasında tutar. Kayıtlar sadece sona eklenir,
+// böylece her izlenen işlem açıkça budanana kadar geri alınabilir kalır.
+type History struct {
+	path string
+}
+
+func NewHistory(path string) *History {
+	return &History{
+		path: path,
+	}
+}
+
+func (h *History) Append(record BackupRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf(lang.Get("json_marshal_error"), err)
+	}
+
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf(lang.Get("history_write_error"), err)
+	}
+
+	// Kayıt diske işlenmeden komut çalıştırılmamalı; yarım kalan bir satır
+	// açılışta repairTail ile kesilir
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = atomicfile.SyncDir(filepath.Dir(h.path))
+	}
+	if err != nil {
+		return fmt.Errorf(lang.Get("history_write_error"), err)
+	}
+
+	return nil
+}
+
+// repairTail, günlüğün sonunda satır sonuyla bitmeyen (yazılırken yarıda
+// kalmış) bir kayıt varsa onu keser ve kesilen bayt sayısını döndürür.
+func (h *History) repairTail() (int64, error) {
+	file, err := os.OpenFile(h.path, os.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return 0, err
+	}
+
+	// Son satır sonunu bulmak için dosyayı sondan geriye doğru oku
+	buf := make([]byte, 64*1024)
+	end := info.Size()
+	for end > 0 {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+		if _, err := file.ReadAt(chunk, start); err != nil {
+			return 0, err
+		}
+
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+
+	if end == info.Size() {
+		return 0, nil
+	}
+
+	if err := file.Truncate(end); err != nil {
+		return 0, err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+
+	return info.Size() - end, nil
+}
+
+// Rewrite günlüğü verilen kayıtlarla değiştirir. Yeni içerik
+// atomicfile.WriteFile ile yazılır, böylece yarıda kalan bir yazma mevcut
+// geçmişi bozmaz.
+func (h *History) Rewrite(records []BackupRecord) error {
+	var buf bytes.Buffer
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf(lang.Get("json_marshal_error"), err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	if err := atomicfile.WriteFile(h.path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf(lang.Get("history_write_error"), err)
+	}
+
+	return nil
+}
+
+// Load tüm kayıtları eskiden yeniye doğru sıralı olarak döndürür.
+func (h *History) Load() ([]BackupRecord, error) {
+	file, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf(lang.Get("history_read_error"), err)
+	}
+	defer file.Close()
+
+	// Satır uzunluğu sınırlı değildir; büyük bir ağacı silen tek bir işlem
+	// megabaytlarca tutan bir kayıt üretebilir
+	var records []BackupRecord
+	var pending error
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf(lang.Get("history_read_error"), readErr)
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			if pending != nil {
+				return nil, pending
+			}
+
+			// Çözülemeyen satır son satırsa, yarıda kalmış (henüz onarılmamış)
+			// bir eklemedir ve yok sayılır
+			var record BackupRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				pending = fmt.Errorf(lang.Get("backup_record_read_error"), err)
+			} else {
+				records = append(records, record)
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// HasID, verilen ID'ye sahip bir kaydın geçmişte olup olmadığını bildirir.
+// Her satır çözülmez; sadece ID'yi içeren satırların id alanına bakılır.
+func (h *History) HasID(id string) (bool, error) {
+	file, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf(lang.Get("history_read_error"), err)
+	}
+	defer file.Close()
+
+	needle := []byte(id)
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return false, fmt.Errorf(lang.Get("history_read_error"), readErr)
+		}
+
+		if bytes.Contains(line, needle) {
+			var record struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(line, &record) == nil && record.ID == id {
+				return true, nil
+			}
+		}
+
+		if readErr == io.EOF {
+			return false, nil
+		}
+	}
+}
+
+// Last en son eklenen kaydı döndürür.
+func (h *History) Last() (*BackupRecord, error) {
+	return h.Step(1)
+}
+
+// Find verilen ID ile eşleşen kaydı döndürür. Tek bir kayıtla eşleştiği
+// sürece ID'nin başlangıcını vermek yeterlidir.
+func (h *History) Find(id string) (*BackupRecord, error) {
+	records, err := h.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var match *BackupRecord
+	for i := range records {
+		if records[i].ID == id {
+			return &records[i], nil
+		}
+		if strings.HasPrefix(records[i].ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf(lang.Get("operation_id_ambiguous"), id)
+			}
+			match = &records[i]
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf(lang.Get("operation_not_found"), id)
+	}
+
+	return match, nil
+}
+
+// Step sondan geriye doğru sayarak kayıt döndürür; 1 en son işlemdir.
+func (h *History) Step(steps int) (*BackupRecord, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf(lang.Get("invalid_steps"), steps)
+	}
+
+	records, err := h.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf(lang.Get("history_empty"))
+	}
+
+	if steps > len(records) {
+		return nil, fmt.Errorf(lang.Get("steps_out_of_range"), steps, len(records))
+	}
+
+	return &records[len(records)-steps], nil
+}
+
+// HistoryFilter, geçmiş kayıtlarını tarih aralığına, komut adına ve
+// etkilenen dosyaların yol önekine göre süzer. Boş alanlar süzme yapmaz.
+type HistoryFilter struct {
+	Since      time.Time
+	Until      time.Time
+	Command    string
+	PathPrefix string
+}
+
+func (f HistoryFilter) Match(record BackupRecord) bool {
+	if !f.Since.IsZero() && record.Timestamp.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && record.Timestamp.After(f.Until) {
+		return false
+	}
+
+	if f.Command != "" && record.Command != f.Command {
+		return false
+	}
+
+	if f.PathPrefix != "" {
+		for _, fileInfo := range record.Files {
+			if hasPathPrefix(fileInfo.OriginalPath, f.PathPrefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// hasPathPrefix, path'in prefix dizininin kendisi ya da altında olup
+// olmadığını kontrol eder ("/tmp/a" öneki "/tmp/ab" ile eşleşmez).
+func hasPathPrefix(path, prefix string) bool {
+	prefix = filepath.Clean(prefix)
+	if path == prefix {
+		return true
+	}
+
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+
+	return strings.HasPrefix(path, prefix)
+}
+
+// parseHistoryTime, log filtrelerinde kullanılan tarih biçimlerini çözer.
+// Sadece tarih verilmişse endOfDay günün sonunu seçer.
+func parseHistoryTime(value string, endOfDay bool) (time.Time, error) {
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(lang.Get("invalid_date"), value)
+	}
+
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return t, nil
+}
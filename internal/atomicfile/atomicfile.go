@@ -0,0 +1,45 @@
+// Package atomicfile, çökme veya disk dolması durumunda yarım kalmayan
+// dosya yazmalarını sağlar. Ana paket ve lang paketi aynı yardımcıyı
+// kullanır; böylece başlangıçtaki kurtarma, her ikisinin de bıraktığı
+// geçici dosyaları aynı önekle bulur.
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// TempPrefix, yarıda kalan yazmalardan geriye kalan geçici dosyaların öneki.
+const TempPrefix = ".tmp-"
+
+// WriteFile, içeriği aynı dizinde geçici bir dosyaya yazar, diske işler
+// (fsync) ve hedefin üzerine taşır. Çökme veya disk dolması durumunda
+// hedef ya eski ya da yeni içeriğin tamamını içerir.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmpFile, err := os.CreateTemp(dir, TempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return SyncDir(dir)
+}
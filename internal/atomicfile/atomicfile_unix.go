@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package atomicfile
+
+import "os"
+
+// SyncDir, dizindeki oluşturma ve yeniden adlandırmaların çökmeden sonra da
+// kalıcı olması için dizinin kendisini diske işler.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
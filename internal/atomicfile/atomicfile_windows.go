@@ -0,0 +1,7 @@
+package atomicfile
+
+// Windows'ta dizinler fsync ile işlenemez; NTFS yeniden adlandırmayı
+// günlüğüne kendisi yazar.
+func SyncDir(dir string) error {
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"sysundo/internal/atomicfile"
 )
 
 type LangManager struct {
@@ -85,16 +86,28 @@ func (lm *LangManager) loadSavedLanguage() string {
 }
 
 func (lm *LangManager) saveLangaugeConfig() error {
-	config := LangConfig{
-		Language: lm.currentLang,
+	// Aynı dosyadaki yedekleme ayarlarını koru, sadece dil anahtarını değiştir
+	config := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(lm.configPath); err == nil {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return err
+		}
+	}
+
+	language, err := json.Marshal(lm.currentLang)
+	if err != nil {
+		return err
 	}
+	config["language"] = language
 
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(lm.configPath, data, 0644)
+	// Dosya yedekleme ayarlarını (şifreleme, güvenlik seviyesi) da tuttuğu
+	// için yarıda kalan bir yazma onu bozmamalıdır
+	return atomicfile.WriteFile(lm.configPath, data, 0644)
 }
 
 func (lm *LangManager) LoadLanguage(langCode string) error {
@@ -188,7 +201,7 @@ func (lm *LangManager) Get(key string, args ...interface{}) string {
 		"app_description":   "sysundo - Automatic backup tool for system file operations",
 		"usage":             "Usage:",
 		"watch_usage":       "sysundo watch <command> [arguments...]  - Execute command while backing up files",
-		"undo_usage":        "sysundo undo                          - Restore last backups",
+		"undo_usage":        "sysundo undo [id | --steps N]         - Restore the last or a specific operation",
 		"help_usage":        "sysundo help                          - Show this help text",
 		"lang_usage":        "sysundo lang [language_code]          - Set language or show available languages",
 		"examples":          "Examples:",
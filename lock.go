@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sysundo/lang"
+	"time"
+)
+
+// Depoyu değiştiren her işlem (yedekleme, kayıt ekleme, geri alma, gc,
+// sabitleme, anahtar değişimi) ~/.sysundo/lock üzerinde özel bir kilit
+// alır. Aynı anda çalışan sysundo süreçleri sırayla ilerler; kilit bu süre
+// içinde alınamazsa işlem hata ile sonlanır.
+const (
+	lockFileName   = "lock"
+	lockTimeout    = time.Minute
+	lockRetryDelay = 100 * time.Millisecond
+	lockStaleAge   = 10 * time.Second // PID yazılmamış kilit dosyaları bu süreden sonra terk edilmiş sayılır
+	lockNoticeWait = time.Second      // Kısa beklemeler için mesaj gösterilmez
+)
+
+// StoreLock, alınmış bir depo kilidi. Unlock ile bırakılır; süreç
+// sonlanırsa flock kilidi çekirdek tarafından kendiliğinden bırakılır.
+type StoreLock struct {
+	file    *os.File // flock kilidi tutulan dosya
+	pidPath string   // flock desteklenmiyorsa kullanılan PID dosyası
+}
+
+// Lock depo kilidini alır. Kilit başka bir süreçteyse lockTimeout boyunca
+// bekler. Kilit alındıktan sonra önceki çalışmalardan kalan yarım dosyalar
+// temizlenir; bu sırada başka bir sürecin yazıyor olması mümkün değildir.
+func (bm *BackupManager) Lock() (*StoreLock, error) {
+	if err := os.MkdirAll(bm.baseDir, 0755); err != nil {
+		return nil, fmt.Errorf(lang.Get("lock_error"), err)
+	}
+
+	path := filepath.Join(bm.baseDir, lockFileName)
+	started := time.Now()
+	deadline := started.Add(lockTimeout)
+	waiting := false
+	for {
+		lock, holder, err := tryLock(path)
+		if err != nil {
+			return nil, fmt.Errorf(lang.Get("lock_error"), err)
+		}
+		if lock != nil {
+			bm.recover()
+			return lock, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf(lang.Get("lock_timeout"), lockTimeout, lockHolder(holder))
+		}
+		if !waiting && time.Since(started) >= lockNoticeWait {
+			fmt.Printf(lang.Get("lock_waiting")+"\n", lockHolder(holder))
+			waiting = true
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// Unlock kilidi bırakır.
+func (l *StoreLock) Unlock() {
+	if l.pidPath != "" {
+		os.Remove(l.pidPath)
+		return
+	}
+	unlockFile(l.file)
+	l.file.Close()
+}
+
+// tryPIDLock, flock kullanılamayan sistemlerde kilidi O_EXCL ile
+// oluşturulan bir PID dosyasıyla taklit eder. Dosyayı oluşturan süreç artık
+// çalışmıyorsa kilit terk edilmiş sayılır ve silinir.
+func tryPIDLock(path string) (*StoreLock, int, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		_, err = fmt.Fprintf(file, "%d\n", os.Getpid())
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			return nil, 0, err
+		}
+		return &StoreLock{pidPath: path}, 0, nil
+	}
+	if !os.IsExist(err) {
+		return nil, 0, err
+	}
+
+	pid := readLockPID(path)
+	if pid > 0 && processAlive(pid) {
+		return nil, pid, nil
+	}
+
+	// PID'si olmayan dosya henüz yazılıyor olabilir; sadece eskiyse sil
+	if pid == 0 {
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < lockStaleAge {
+			return nil, pid, nil
+		}
+	}
+
+	// Silmeden hemen önce dosyanın hâlâ aynı sürece ait olduğunu kontrol et
+	if readLockPID(path) == pid {
+		os.Remove(path)
+	}
+	return nil, pid, nil
+}
+
+// lockHolder, kilidi tutan süreci mesajlarda gösterilecek biçimde döndürür.
+// Kilit yeni alındıysa PID henüz yazılmamış olabilir.
+func lockHolder(pid int) string {
+	if pid <= 0 {
+		return lang.Get("lock_holder_unknown")
+	}
+	return fmt.Sprintf(lang.Get("lock_holder"), pid)
+}
+
+// readLockPID kilit dosyasındaki süreç numarasını okur, okunamazsa 0 döner.
+func readLockPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,57 @@
+//go:build linux || darwin || freebsd
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// tryLock, kilit dosyası üzerinde beklemeden flock almayı dener. Kilit
+// başka bir süreçteyse nil ve (biliniyorsa) o sürecin numarası döner.
+// flock kilitleri sahibi sonlandığında çekirdek tarafından bırakıldığı için
+// terk edilmiş kilit oluşmaz. Dosya sistemi flock desteklemiyorsa (bazı
+// ağ dosya sistemleri) PID dosyasına geçilir.
+func tryLock(path string) (*StoreLock, int, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		file.Close()
+		// Dosyadaki PID kilidi flock ile alan başka bir araca ait olmayabilir
+		pid := readLockPID(path)
+		if pid > 0 && !processAlive(pid) {
+			pid = 0
+		}
+		return nil, pid, nil
+	}
+	if errors.Is(err, syscall.ENOLCK) || errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOSYS) {
+		file.Close()
+		return tryPIDLock(path + ".pid")
+	}
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+
+	// Bekleyen süreçlere kimin beklettiğini göstermek için PID'yi yaz
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+	}
+
+	return &StoreLock{file: file}, 0, nil
+}
+
+func unlockFile(file *os.File) {
+	syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,21 @@
+package main
+
+import "os"
+
+// Windows'ta syscall paketinde flock karşılığı bulunmadığından kilit her
+// zaman PID dosyasıyla alınır.
+func tryLock(path string) (*StoreLock, int, error) {
+	return tryPIDLock(path + ".pid")
+}
+
+func unlockFile(file *os.File) {}
+
+// FindProcess Windows'ta süreci açmaya çalışır; süreç yoksa hata döner.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sysundo/lang"
 )
 
+// exitBlocked, sysundo'nun komutu hiç çalıştırmadığını belirten çıkış kodu.
+// rm, mv ve cp başarısızlıkta 1 ile çıktığı için onlarla karışmaz.
+const exitBlocked = 3
+
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
@@ -23,7 +32,21 @@ func main() {
 		}
 		handleWatchMode(os.Args[2:])
 	case "undo":
-		handleUndoMode()
+		handleUndoMode(os.Args[2:])
+	case "log", "list":
+		handleLogMode(os.Args[2:])
+	case "config":
+		handleConfigMode(os.Args[2:])
+	case "stats":
+		handleStatsMode()
+	case "key":
+		handleKeyMode(os.Args[2:])
+	case "gc":
+		handleGCMode(os.Args[2:])
+	case "verify":
+		handleVerifyMode(os.Args[2:])
+	case "pin", "unpin":
+		handlePinMode(command == "pin", os.Args[2:])
 	case "lang":
 		handleLangMode(os.Args[2:])
 	case "help", "-h", "--help":
@@ -41,6 +64,13 @@ func printUsage() {
 	fmt.Println(lang.Get("usage"))
 	fmt.Println("  " + lang.Get("watch_usage"))
 	fmt.Println("  " + lang.Get("undo_usage"))
+	fmt.Println("  " + lang.Get("log_usage"))
+	fmt.Println("  " + lang.Get("config_usage"))
+	fmt.Println("  " + lang.Get("stats_usage"))
+	fmt.Println("  " + lang.Get("key_usage"))
+	fmt.Println("  " + lang.Get("gc_usage"))
+	fmt.Println("  " + lang.Get("pin_usage"))
+	fmt.Println("  " + lang.Get("verify_usage"))
 	fmt.Println("  " + lang.Get("help_usage"))
 	fmt.Println("  " + lang.Get("lang_usage"))
 	fmt.Println()
@@ -48,23 +78,87 @@ func printUsage() {
 	fmt.Println("  " + lang.Get("example_watch_rm"))
 	fmt.Println("  " + lang.Get("example_watch_mv"))
 	fmt.Println("  " + lang.Get("example_watch_cp"))
+	fmt.Println("  " + lang.Get("example_watch_strict"))
 	fmt.Println("  " + lang.Get("example_undo"))
+	fmt.Println("  " + lang.Get("example_undo_id"))
+	fmt.Println("  " + lang.Get("example_undo_steps"))
+	fmt.Println("  " + lang.Get("example_undo_partial"))
+	fmt.Println("  " + lang.Get("example_log"))
+	fmt.Println("  " + lang.Get("example_config_set"))
+	fmt.Println("  " + lang.Get("example_key_init"))
+	fmt.Println("  " + lang.Get("example_gc"))
 	fmt.Println("  " + lang.Get("example_lang_set"))
 	fmt.Println("  " + lang.Get("example_lang_list"))
 }
 
 func handleWatchMode(args []string) {
-	watcher := NewFileWatcher()
-	err := watcher.ExecuteWithBackup(args)
-	if err != nil {
-		fmt.Printf(lang.Get("error")+"\n", err)
+	strict := false
+	if args[0] == "--strict" {
+		strict = true
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Println(lang.Get("watch_command_usage"))
 		os.Exit(1)
 	}
+
+	watcher, err := NewFileWatcher(strict)
+	if err == nil {
+		err = watcher.ExecuteWithBackup(args)
+	}
+	if err == nil {
+		return
+	}
+
+	// Komut çalıştıysa ve başarısız olduysa kendi çıkış koduyla çık; hata
+	// mesajını komut zaten yazdı
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		os.Exit(exitErr.ExitCode())
+	}
+
+	fmt.Printf(lang.Get("error")+"\n", err)
+	var blockedErr *BlockedError
+	if errors.As(err, &blockedErr) {
+		os.Exit(exitBlocked)
+	}
+	os.Exit(1)
 }
 
-func handleUndoMode() {
-	restorer := NewFileRestorer()
-	err := restorer.RestoreLastBackup()
+func handleUndoMode(args []string) {
+	partial := false
+	force := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--partial" {
+			partial = true
+		} else if arg == "--force" {
+			force = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	args = rest
+
+	restorer := NewFileRestorer(partial, force)
+
+	var err error
+	if len(args) == 0 {
+		err = restorer.RestoreLastBackup()
+	} else if value, next, ok := takeOption(args, 0, "--steps"); ok && next == len(args)-1 {
+		steps, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			fmt.Printf(lang.Get("invalid_steps")+"\n", value)
+			os.Exit(1)
+		}
+		err = restorer.RestoreSteps(steps)
+	} else if len(args) == 1 && !strings.HasPrefix(args[0], "-") {
+		err = restorer.RestoreOperation(args[0])
+	} else {
+		fmt.Println(lang.Get("undo_command_usage"))
+		os.Exit(1)
+	}
+
 	if err != nil {
 		fmt.Printf(lang.Get("undo_error")+"\n", err)
 		os.Exit(1)
@@ -72,6 +166,288 @@ func handleUndoMode() {
 	fmt.Println(lang.Get("last_backups_restored"))
 }
 
+func handleLogMode(args []string) {
+	var filter HistoryFilter
+
+	for i := 0; i < len(args); i++ {
+		var err error
+		if value, next, ok := takeOption(args, i, "--since"); ok {
+			filter.Since, err = parseHistoryTime(value, false)
+			i = next
+		} else if value, next, ok := takeOption(args, i, "--until"); ok {
+			filter.Until, err = parseHistoryTime(value, true)
+			i = next
+		} else if value, next, ok := takeOption(args, i, "--command"); ok {
+			filter.Command = value
+			i = next
+		} else if value, next, ok := takeOption(args, i, "--path"); ok {
+			filter.PathPrefix, err = filepath.Abs(value)
+			i = next
+		} else {
+			fmt.Println(lang.Get("log_command_usage"))
+			os.Exit(1)
+		}
+
+		if err != nil {
+			fmt.Printf(lang.Get("error")+"\n", err)
+			os.Exit(1)
+		}
+	}
+
+	restorer := NewFileRestorer(false, false)
+	err := restorer.ListBackups(filter)
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+}
+
+func handleConfigMode(args []string) {
+	if len(args) == 0 {
+		fmt.Println(lang.Get("config_command_usage"))
+		os.Exit(1)
+	}
+
+	// --dir seçeneği herhangi bir konumda verilebilir
+	dir := ""
+	var positional []string
+	for i := 1; i < len(args); i++ {
+		if value, next, ok := takeOption(args, i, "--dir"); ok {
+			absDir, err := filepath.Abs(expandHome(value))
+			if err != nil {
+				fmt.Printf(lang.Get("error")+"\n", err)
+				os.Exit(1)
+			}
+			dir = absDir
+			i = next
+			continue
+		}
+		positional = append(positional, args[i])
+	}
+
+	// set, okunamayan veya geçersiz bir yapılandırmayı düzeltmek için de
+	// kullanılabilmeli
+	var config *Config
+	if args[0] != "set" {
+		var err error
+		config, err = LoadConfig()
+		if err != nil {
+			fmt.Printf(lang.Get("error")+"\n", err)
+			os.Exit(1)
+		}
+	}
+
+	switch {
+	case args[0] == "show" && len(positional) == 0:
+		if dir != "" {
+			config = config.ForPath(dir)
+		}
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			fmt.Printf(lang.Get("error")+"\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	case args[0] == "get" && len(positional) == 1:
+		value, err := GetConfigValue(config, positional[0], dir)
+		if err != nil {
+			fmt.Printf(lang.Get("error")+"\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	case args[0] == "set" && len(positional) == 2:
+		err := SetConfigValue(positional[0], positional[1], dir)
+		if err != nil {
+			fmt.Printf(lang.Get("error")+"\n", err)
+			os.Exit(1)
+		}
+		if dir != "" {
+			fmt.Printf(lang.Get("config_set_dir")+"\n", positional[0], positional[1], dir)
+		} else {
+			fmt.Printf(lang.Get("config_set")+"\n", positional[0], positional[1])
+		}
+	default:
+		fmt.Println(lang.Get("config_command_usage"))
+		fmt.Printf(lang.Get("config_available_keys")+"\n", strings.Join(ConfigKeys(), ", "))
+		os.Exit(1)
+	}
+}
+
+func handleStatsMode() {
+	backupManager := NewBackupManager()
+
+	records, err := backupManager.history.Load()
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+
+	stats, err := backupManager.store.Stats(records)
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(lang.Get("stats_operations")+"\n", stats.Operations)
+	fmt.Printf(lang.Get("stats_files")+"\n", stats.Files, formatSize(stats.LogicalSize))
+	fmt.Printf(lang.Get("stats_objects")+"\n", stats.Objects, formatSize(stats.OriginalSize))
+	fmt.Printf(lang.Get("stats_encrypted")+"\n", stats.Encrypted)
+	fmt.Printf(lang.Get("stats_stored")+"\n", formatSize(stats.StoredSize))
+	fmt.Printf(lang.Get("stats_compression_ratio")+"\n", stats.CompressionRatio())
+	if stats.LogicalSize > 0 {
+		saved := 100 * (1 - float64(stats.StoredSize)/float64(stats.LogicalSize))
+		fmt.Printf(lang.Get("stats_space_saved")+"\n", saved)
+	}
+}
+
+func handleKeyMode(args []string) {
+	if len(args) != 1 {
+		fmt.Println(lang.Get("key_command_usage"))
+		os.Exit(1)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+
+	backupManager := NewBackupManager()
+	lock, err := backupManager.Lock()
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+	defer lock.Unlock()
+
+	switch args[0] {
+	case "init":
+		if err := backupManager.keys.Init(config); err != nil {
+			fmt.Printf(lang.Get("error")+"\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(lang.Get("key_initialized"))
+	case "rotate":
+		count, err := backupManager.keys.Rotate(config, backupManager.reencryptStore)
+		if err != nil {
+			fmt.Printf(lang.Get("error")+"\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf(lang.Get("key_rotated")+"\n", count)
+	default:
+		fmt.Println(lang.Get("key_command_usage"))
+		os.Exit(1)
+	}
+}
+
+func handleGCMode(args []string) {
+	dryRun := false
+	for _, arg := range args {
+		if arg != "--dry-run" && arg != "-n" {
+			fmt.Println(lang.Get("gc_command_usage"))
+			os.Exit(1)
+		}
+		dryRun = true
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+
+	result, err := NewBackupManager().GarbageCollect(config, dryRun)
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+
+	printGCSummary(result, dryRun)
+}
+
+// printGCSummary budanan işlemleri ve serbest kalan alanı gösterir.
+func printGCSummary(result *GCResult, dryRun bool) {
+	for _, record := range result.RemovedRecords {
+		line := strings.TrimSpace(record.Command + " " + strings.Join(record.Args, " "))
+		fmt.Printf(lang.Get("gc_pruned_operation")+"\n", record.ID, record.Timestamp.Local().Format("2006-01-02 15:04"), line)
+	}
+
+	summary := lang.Get("gc_summary")
+	if dryRun {
+		summary = lang.Get("gc_dry_run_summary")
+	}
+	fmt.Printf(summary+"\n", len(result.RemovedRecords), result.RemovedObjects, formatSize(result.FreedSize), result.KeptRecords)
+}
+
+func handleVerifyMode(args []string) {
+	quick := false
+	for _, arg := range args {
+		if arg != "--quick" {
+			fmt.Println(lang.Get("verify_command_usage"))
+			os.Exit(1)
+		}
+		quick = true
+	}
+
+	report, err := NewBackupManager().Verify(quick)
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+
+	for _, issue := range report.Missing {
+		fmt.Printf(lang.Get("verify_missing")+"\n", issue.RecordID, issue.OriginalPath)
+	}
+	for _, issue := range report.Corrupt {
+		fmt.Printf(lang.Get("verify_corrupt")+"\n", issue.RecordID, issue.OriginalPath, issue.Err)
+	}
+	for _, object := range report.Orphaned {
+		fmt.Printf(lang.Get("verify_orphaned")+"\n", object.Hash, formatSize(object.StoredSize))
+	}
+
+	fmt.Printf(lang.Get("verify_summary")+"\n", report.Checked, len(report.Missing), len(report.Corrupt), len(report.Orphaned))
+	if len(report.Orphaned) > 0 {
+		fmt.Println(lang.Get("verify_orphaned_hint"))
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+func handlePinMode(pinned bool, args []string) {
+	if len(args) != 1 {
+		fmt.Println(lang.Get("pin_command_usage"))
+		os.Exit(1)
+	}
+
+	record, err := NewBackupManager().SetPinned(args[0], pinned)
+	if err != nil {
+		fmt.Printf(lang.Get("error")+"\n", err)
+		os.Exit(1)
+	}
+
+	if pinned {
+		fmt.Printf(lang.Get("operation_pinned")+"\n", record.ID)
+	} else {
+		fmt.Printf(lang.Get("operation_unpinned")+"\n", record.ID)
+	}
+}
+
+// takeOption args[i] içindeki "--name value" veya "--name=value" biçimindeki
+// seçeneği okur ve değerin bulunduğu son indeksi döndürür.
+func takeOption(args []string, i int, name string) (string, int, bool) {
+	if strings.HasPrefix(args[i], name+"=") {
+		return strings.TrimPrefix(args[i], name+"="), i, true
+	}
+
+	if args[i] == name && i+1 < len(args) {
+		return args[i+1], i + 1, true
+	}
+
+	return "", i, false
+}
+
 func handleLangMode(args []string) {
 	if len(args) == 0 {
 		// Mevcut dili ve mevcut dilleri göster
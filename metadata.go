@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sysundo/lang"
+	"time"
+)
+
+// FileMetadata, izinler dışında geri yüklemede korunması gereken dosya
+// bilgilerini tutar. Platformun desteklemediği alanlar boş kalır: sahiplik
+// sadece Unix sistemlerinde, genişletilmiş öznitelikler (POSIX ACL'ler dahil)
+// sadece Linux'ta kaydedilir.
+type FileMetadata struct {
+	ModTime    time.Time         `json:"mtime"`
+	AccessTime time.Time         `json:"atime"`
+	Owner      *FileOwner        `json:"owner,omitempty"`
+	Xattrs     map[string][]byte `json:"xattrs,omitempty"`
+}
+
+type FileOwner struct {
+	UID int `json:"uid"`
+	GID int `json:"gid"`
+}
+
+// modeBits, izinlerle birlikte setuid, setgid ve sticky bitlerini döndürür.
+func modeBits(info os.FileInfo) os.FileMode {
+	return info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+}
+
+// captureMetadata, path'in meta verilerini okur. Okunamayan öznitelikler
+// yedeklemeyi engellemez, sadece kaydedilmez.
+func captureMetadata(path string, info os.FileInfo) *FileMetadata {
+	meta := &FileMetadata{
+		ModTime:    info.ModTime(),
+		AccessTime: accessTime(info),
+		Owner:      fileOwner(info),
+	}
+
+	if xattrs, err := readXattrs(path); err == nil && len(xattrs) > 0 {
+		meta.Xattrs = xattrs
+	}
+
+	return meta
+}
+
+// applyMetadata, sahiplik, izin, genişletilmiş öznitelik ve zamanları bu
+// sırayla uygular: chown setuid/setgid bitlerini temizlediği için izinler
+// ondan sonra, öznitelik yazımı zamanları etkileyebildiği için zamanlar en
+// son uygulanır. İzin hatası geri yüklemeyi durdurur; diğerleri (örneğin
+// root olmadan başka bir kullanıcıya ait dosyanın sahipliği) sadece uyarı
+// olarak döner.
+func applyMetadata(path string, mode os.FileMode, meta *FileMetadata) (warning error, err error) {
+	var warnings []string
+
+	if meta != nil && meta.Owner != nil {
+		if err := os.Lchown(path, meta.Owner.UID, meta.Owner.GID); err != nil {
+			warnings = append(warnings, fmt.Sprintf(lang.Get("owner_restore_warning"), meta.Owner.UID, meta.Owner.GID, permissionHint(err)))
+		}
+	}
+
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if meta == nil {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(meta.Xattrs))
+	for name := range meta.Xattrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeXattr(path, name, meta.Xattrs[name]); err != nil {
+			warnings = append(warnings, fmt.Sprintf(lang.Get("xattr_restore_warning"), name, permissionHint(err)))
+		}
+	}
+
+	if !meta.ModTime.IsZero() {
+		atime := meta.AccessTime
+		if atime.IsZero() {
+			atime = meta.ModTime
+		}
+		if err := os.Chtimes(path, atime, meta.ModTime); err != nil {
+			warnings = append(warnings, fmt.Sprintf(lang.Get("times_restore_warning"), err))
+		}
+	}
+
+	if len(warnings) > 0 {
+		return errors.New(strings.Join(warnings, "; ")), nil
+	}
+	return nil, nil
+}
+
+// permissionHint, yetki hatalarını root gerektiği bilgisiyle açıklar.
+func permissionHint(err error) string {
+	if errors.Is(err, os.ErrPermission) && os.Geteuid() != 0 {
+		return lang.Get("requires_root")
+	}
+	return err.Error()
+}
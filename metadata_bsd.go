@@ -0,0 +1,27 @@
+//go:build darwin || freebsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func accessTime(info os.FileInfo) time.Time {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(int64(stat.Atimespec.Sec), int64(stat.Atimespec.Nsec))
+}
+
+// Genişletilmiş öznitelikler için syscall paketinde bu sistemlerde bir
+// arayüz bulunmadığından kaydedilmez.
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func writeXattr(path, name string, value []byte) error {
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"time"
+)
+
+func accessTime(info os.FileInfo) time.Time {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+}
+
+// readXattrs, dosyanın okunabilen tüm genişletilmiş özniteliklerini okur.
+// POSIX ACL'ler system.posix_acl_access ve system.posix_acl_default
+// öznitelikleri olarak bu listeye dahildir.
+func readXattrs(path string) (map[string][]byte, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	size, err = syscall.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string][]byte)
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+
+		valueSize, err := syscall.Getxattr(path, string(name), nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = syscall.Getxattr(path, string(name), value)
+		if err != nil {
+			continue
+		}
+		xattrs[string(name)] = value[:valueSize]
+	}
+
+	return xattrs, nil
+}
+
+func writeXattr(path, name string, value []byte) error {
+	return syscall.Setxattr(path, name, value, 0)
+}
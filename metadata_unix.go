@@ -0,0 +1,28 @@
+//go:build linux || darwin || freebsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func fileOwner(info os.FileInfo) *FileOwner {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return &FileOwner{UID: int(stat.Uid), GID: int(stat.Gid)}
+}
+
+// fileIdentity, birden fazla sabit bağı olan dosyalar için aygıt ve inode
+// numarasından oluşan bir kimlik döndürür. Aynı kimliğe sahip yollar aynı
+// dosyanın sabit bağlarıdır.
+func fileIdentity(info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", uint64(stat.Dev), uint64(stat.Ino))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func accessTime(info os.FileInfo) time.Time {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(0, data.LastAccessTime.Nanoseconds())
+}
+
+// Windows'ta Unix sahipliği ve genişletilmiş öznitelikler yoktur.
+func fileOwner(info os.FileInfo) *FileOwner {
+	return nil
+}
+
+// Sabit bağlar Windows'ta takip edilmez, her yol ayrı dosya olarak kaydedilir.
+func fileIdentity(info os.FileInfo) string {
+	return ""
+}
+
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func writeXattr(path, name string, value []byte) error {
+	return nil
+}
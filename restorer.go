@@ -1,102 +1,691 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sysundo/lang"
+	"time"
 )
 
 type FileRestorer struct {
 	backupManager *BackupManager
+	partial       bool // Geri yüklenemeyen yolları atla, gerisini geri yükle
+	force         bool // İşlemden sonra değiştirilmiş hedeflerin de üzerine yaz
 }
 
-func NewFileRestorer() *FileRestorer {
+func NewFileRestorer(partial, force bool) *FileRestorer {
 	return &FileRestorer{
 		backupManager: NewBackupManager(),
+		partial:       partial,
+		force:         force,
 	}
 }
 
 func (fr *FileRestorer) RestoreLastBackup() error {
-	// Son yedekleme kaydını oku
-	recordPath := filepath.Join(fr.backupManager.backupDir, "last_backup.json")
+	return fr.RestoreSteps(1)
+}
 
-	data, err := os.ReadFile(recordPath)
+// RestoreSteps sondan steps'inci işlemi geri yükler; 1 en son işlemdir.
+func (fr *FileRestorer) RestoreSteps(steps int) error {
+	lock, err := fr.backupManager.Lock()
 	if err != nil {
-		return fmt.Errorf(lang.Get("backup_record_not_found"), err)
+		return err
 	}
+	defer lock.Unlock()
 
-	var record BackupRecord
-	err = json.Unmarshal(data, &record)
+	record, err := fr.backupManager.history.Step(steps)
 	if err != nil {
-		return fmt.Errorf(lang.Get("backup_record_read_error"), err)
+		return err
 	}
 
-	// Her dosyayı geri yükle
-	restoredCount := 0
+	return fr.RestoreRecord(record)
+}
+
+// RestoreOperation ID'si (veya ID'nin benzersiz başlangıcı) verilen işlemi geri yükler.
+func (fr *FileRestorer) RestoreOperation(id string) error {
+	lock, err := fr.backupManager.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	record, err := fr.backupManager.history.Find(id)
+	if err != nil {
+		return err
+	}
+
+	return fr.RestoreRecord(record)
+}
+
+// stagedFile, hedefinin yanında hazırlanmış ve yerine taşınmayı bekleyen
+// bir dosya. tmpPath boşsa hedef zaten doğru durumdadır.
+type stagedFile struct {
+	path    string
+	tmpPath string
+}
+
+// RestoreRecord bir işlemi geri alır. Önce taşınan yollar geri taşınır ve
+// eksik dizinler oluşturulur, ardından her dosya hedefinin yanında geçici
+// bir dosyaya hazırlanır ve doğrulanır; ancak hepsi hazırsa yeniden
+// adlandırmayla yerlerine konur. Herhangi bir adım başarısız olursa o ana
+// kadar yapılan her şey geri sarılır. partial ise başarısız yollar uyarıyla
+// atlanır ve diğerleri geri yüklenir.
+func (fr *FileRestorer) RestoreRecord(record *BackupRecord) error {
+	fmt.Printf(lang.Get("restoring_operation")+"\n", record.ID,
+		record.Command, strings.Join(record.Args, " "))
+
+	tx := &restoreTx{}
+	abort := func(err error) error {
+		tx.rollback()
+		return fmt.Errorf(lang.Get("restore_aborted"), err)
+	}
+
+	// mv ile taşınan yolları geri taşı, dizin ağacı kurulmadan önce yapılmalı
+	var movedBack []MovedPath
+	if record.Effects != nil {
+		for i := len(record.Effects.Moved) - 1; i >= 0; i-- {
+			moved := record.Effects.Moved[i]
+			err := fr.moveBack(moved)
+			if err != nil {
+				// Taşınan yol kaybolduysa dosyaları aşağıda yedekten geri yüklenir
+				if fr.partial || os.IsNotExist(err) {
+					fmt.Printf(lang.Get("move_back_warning")+"\n", moved.To, moved.From, err)
+					continue
+				}
+				return abort(fmt.Errorf(lang.Get("file_restore_failed"), moved.From, err))
+			}
+			tx.onRollback(moved.To, func() error {
+				return fr.moveBack(MovedPath{From: moved.To, To: moved.From})
+			})
+			movedBack = append(movedBack, moved)
+		}
+	}
+
+	// Önce dizin ağacını (boş dizinler dahil) yeniden kur
+	createdDirs, failedDirs, err := fr.restoreDirectories(tx, record.Directories)
+	if err != nil {
+		return abort(err)
+	}
+
+	// Her dosyayı hedefinin yanında hazırla; hedeflere henüz dokunulmaz
+	var staged []stagedFile
+	stagedLinks := make(map[string]string) // bağ grubu -> ilk hazırlanan geçici dosya
 	for _, fileInfo := range record.Files {
-		err := fr.restoreFile(fileInfo)
+		// cp kaynaklarına dokunmaz; sonradan silinmiş bir kaynak geri
+		// getirilmez. mv kaynakları geri taşındıysa yerinde bırakılır, bağ
+		// hedefleri de sadece kaybolmuşlarsa geri yüklenir
+		isSource := record.Effects != nil && record.Command != "rm" && fileInfo.Role == ""
+		if isSource && record.Command == "cp" {
+			continue
+		}
+		if isSource || fileInfo.Role == roleLinkTarget {
+			if _, err := os.Lstat(fileInfo.OriginalPath); err == nil {
+				continue
+			}
+		}
+
+		// Oluşturulamayan bir dizinin altındaki dosyalar atlanır
+		if dirErr := failedDirFor(failedDirs, fileInfo.OriginalPath); dirErr != nil {
+			fmt.Printf(lang.Get("file_restore_warning")+"\n", fileInfo.OriginalPath, dirErr)
+			continue
+		}
+
+		// İşlemden sonra yeniden oluşturulan veya değiştirilen bir hedefin
+		// üzerine sadece --force ile yazılır
+		if !fr.force && fr.targetChanged(record, fileInfo) {
+			err := fmt.Errorf(lang.Get("restore_target_changed"))
+			if fr.partial {
+				fmt.Printf(lang.Get("file_restore_warning")+"\n", fileInfo.OriginalPath, err)
+				continue
+			}
+			return abort(fmt.Errorf(lang.Get("file_restore_failed"), fileInfo.OriginalPath, err))
+		}
+
+		// Aynı inode'a ait yollar ilk hazırlanan dosyaya sabit bağ olarak bağlanır
+		var tmpPath string
+		first, linked := stagedLinks[fileInfo.LinkGroup]
+		if linked {
+			tmpPath, err = stageLink(first, fileInfo.OriginalPath)
+		}
+		if !linked || err != nil {
+			tmpPath, err = fr.stageFile(tx, fileInfo)
+		}
 		if err != nil {
-			fmt.Printf(lang.Get("file_restore_warning")+"\n",
-				fileInfo.OriginalPath, err)
-		} else {
-			fmt.Printf(lang.Get("restored")+"\n", fileInfo.OriginalPath)
-			restoredCount++
+			if fr.partial {
+				fmt.Printf(lang.Get("file_restore_warning")+"\n", fileInfo.OriginalPath, err)
+				continue
+			}
+			return abort(fmt.Errorf(lang.Get("file_restore_failed"), fileInfo.OriginalPath, err))
+		}
+
+		if tmpPath != "" {
+			tx.stage(tmpPath)
+			if fileInfo.LinkGroup != "" && !linked {
+				stagedLinks[fileInfo.LinkGroup] = tmpPath
+			}
 		}
+		staged = append(staged, stagedFile{path: fileInfo.OriginalPath, tmpPath: tmpPath})
 	}
 
-	if restoredCount > 0 {
-		fmt.Printf(lang.Get("total_files_restored")+"\n", restoredCount)
-	} else {
+	// Hazırlanan dosyaları yerlerine taşı
+	var restored []string
+	for _, file := range staged {
+		if file.tmpPath != "" {
+			if err := tx.replace(file.tmpPath, file.path); err != nil {
+				if fr.partial {
+					os.Remove(file.tmpPath)
+					fmt.Printf(lang.Get("file_restore_warning")+"\n", file.path, err)
+					continue
+				}
+				return abort(fmt.Errorf(lang.Get("file_restore_failed"), file.path, err))
+			}
+		}
+		restored = append(restored, file.path)
+	}
+
+	// Üzerine yazılan hedeflerin farklı içerikli eski sürümleri kenara
+	// alınan kopyalarla birlikte silinmeden önce geçmişe eklenir
+	savedID, err := fr.saveReplaced(tx, record)
+	if err != nil {
+		return abort(err)
+	}
+
+	// cp ile oluşturulan dosya ve dizinleri sil; bunlar kopya olduğundan
+	// silinemeyenler işlemi geri sarmaz
+	var removed []string
+	if record.Effects != nil {
+		removed = fr.removeCreatedPaths(tx, record.Effects)
+	}
+	tx.finish()
+
+	for _, moved := range movedBack {
+		fmt.Printf(lang.Get("moved_back")+"\n", moved.To, moved.From)
+	}
+	for _, dirInfo := range createdDirs {
+		fmt.Printf(lang.Get("restored")+"\n", dirInfo.Path+string(filepath.Separator))
+	}
+	for _, path := range restored {
+		fmt.Printf(lang.Get("restored")+"\n", path)
+	}
+	for _, path := range removed {
+		fmt.Printf(lang.Get("removed_created")+"\n", path)
+	}
+	removedCount := len(removed)
+	if savedID != "" {
+		fmt.Printf(lang.Get("replaced_saved")+"\n", savedID)
+	}
+
+	// Dizin izinlerini ve zamanlarını en son uygula: salt okunur dizinler
+	// dosya yazımını engellemesin, dosya eklemek de değişiklik zamanını bozmasın
+	for i := len(createdDirs) - 1; i >= 0; i-- {
+		dirInfo := createdDirs[i]
+		warning, err := applyMetadata(dirInfo.Path, dirInfo.Mode, dirInfo.Meta)
+		if err != nil {
+			fmt.Printf(lang.Get("file_restore_warning")+"\n", dirInfo.Path, err)
+		} else if warning != nil {
+			fmt.Printf(lang.Get("metadata_warning")+"\n", dirInfo.Path, warning)
+		}
+	}
+
+	if len(movedBack) > 0 {
+		fmt.Printf(lang.Get("total_moved_back")+"\n", len(movedBack))
+	}
+
+	if removedCount > 0 {
+		fmt.Printf(lang.Get("total_removed")+"\n", removedCount)
+	}
+
+	if len(createdDirs) > 0 {
+		fmt.Printf(lang.Get("total_dirs_restored")+"\n", len(createdDirs))
+	}
+
+	if len(restored) > 0 {
+		fmt.Printf(lang.Get("total_files_restored")+"\n", len(restored))
+	} else if len(createdDirs) == 0 && len(movedBack) == 0 && removedCount == 0 {
 		return fmt.Errorf(lang.Get("no_files_restored"))
 	}
 
 	return nil
 }
 
-func (fr *FileRestorer) restoreFile(fileInfo BackupFileInfo) error {
-	// Yedekleme dosyasının var olduğunu kontrol et
-	if _, err := os.Stat(fileInfo.BackupPath); err != nil {
-		return fmt.Errorf(lang.Get("backup_file_not_found"), err)
+// saveReplaced, geri yükleme sırasında üzerine yazılan hedeflerden içeriği
+// geri yüklenenden farklı olanları yedekler ve "undo" komutu olarak yeni bir
+// işlem kaydına ekler; böylece --force ile ezilen sonraki çalışmalar
+// kaybolmaz ve geri alma da geri alınabilir. Kaydedilecek bir şey yoksa
+// boş ID döner. Çağıran depo kilidini tutmalıdır.
+func (fr *FileRestorer) saveReplaced(tx *restoreTx, record *BackupRecord) (string, error) {
+	var fileInfos []BackupFileInfo
+	var config *Config
+	effects := &OperationEffects{}
+	for _, replaced := range tx.replaced {
+		info, err := os.Lstat(replaced.aside)
+		if err != nil {
+			return "", err
+		}
+
+		var fileInfo *BackupFileInfo
+		if info.Mode()&os.ModeSymlink != 0 {
+			old, _ := os.Readlink(replaced.aside)
+			if current, err := os.Readlink(replaced.path); err == nil && current == old {
+				continue
+			}
+			if fileInfo, err = fr.backupManager.BackupSymlink(replaced.aside); err != nil {
+				return "", err
+			}
+		} else if info.Mode().IsRegular() {
+			old, err := fr.backupManager.hashFile(replaced.aside, false)
+			if err != nil {
+				return "", err
+			}
+			if current, err := fr.backupManager.hashFile(replaced.path, false); err == nil && current == old {
+				continue
+			}
+
+			// Yedek, kullanıcının geçerli yapılandırmasına (şifreleme dahil) uymalı
+			if config == nil {
+				if config, err = LoadConfig(); err != nil {
+					return "", err
+				}
+			}
+			if fileInfo, err = fr.backupManager.BackupFile(replaced.aside, config.ForPath(replaced.path)); err != nil {
+				return "", err
+			}
+
+			keyed := config.encryptsAny()
+			restoredHash, err := fr.backupManager.hashFile(replaced.path, keyed)
+			if err != nil {
+				return "", err
+			}
+			if effects.CreatedHashes == nil {
+				effects.CreatedHashes = make(map[string]string)
+			}
+			effects.CreatedHashes[replaced.path] = restoredHash
+			effects.CreatedKeyed = keyed
+		} else {
+			continue
+		}
+
+		fileInfo.OriginalPath = replaced.path
+		fileInfo.Role = roleOverwritten
+		fileInfos = append(fileInfos, *fileInfo)
+		effects.Overwritten = append(effects.Overwritten, replaced.path)
 	}
 
-	// Hedef dizinin var olduğunu kontrol et, yoksa oluştur
-	targetDir := filepath.Dir(fileInfo.OriginalPath)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return fmt.Errorf(lang.Get("target_dir_create_error"), err)
+	if len(fileInfos) == 0 {
+		return "", nil
 	}
 
-	// Dosyayı geri yükle
-	err := fr.backupManager.copyFile(fileInfo.BackupPath, fileInfo.OriginalPath)
+	saved, err := fr.backupManager.CreateBackupRecord(fileInfos, nil, effects, "undo", []string{record.ID})
 	if err != nil {
+		return "", err
+	}
+
+	return saved.ID, nil
+}
+
+// moveBack, mv ile taşınan bir yolu eski konumuna geri taşır. Eski konumda
+// artık bir şey varsa dokunulmaz; taşınan yol kaybolmuşsa dosyalar yedekten
+// geri yüklenir.
+func (fr *FileRestorer) moveBack(moved MovedPath) error {
+	info, err := os.Lstat(moved.To)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(moved.From); err == nil {
+		return fmt.Errorf(lang.Get("path_already_exists"), moved.From)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(moved.From), 0755); err != nil {
+		return fmt.Errorf(lang.Get("target_dir_create_error"), err)
+	}
+
+	err = os.Rename(moved.To, moved.From)
+	if err == nil || !info.Mode().IsRegular() {
+		return err
+	}
+
+	// Farklı dosya sistemleri arasında rename çalışmaz, kopyalayıp sil
+	if err := fr.backupManager.copyFile(moved.To, moved.From); err != nil {
 		return fmt.Errorf(lang.Get("file_copy_error"), err)
 	}
 
+	return os.Remove(moved.To)
+}
+
+// removeCreatedPaths, cp'nin oluşturduğu yolları geri alma işleminin bir
+// parçası olarak kaldırır ve kaldırılanları döndürür. Alt yollar üst
+// dizinlerden önce ele alınır ve dizinler sadece boşlarsa silinir. Dosyalar
+// sadece içerikleri kopyalama anındaki özetle hâlâ eşleşiyorsa silinir;
+// işlemden sonra değiştirilen veya eklenen dosyalar korunur.
+func (fr *FileRestorer) removeCreatedPaths(tx *restoreTx, effects *OperationEffects) []string {
+	sorted := make([]string, len(effects.Created))
+	copy(sorted, effects.Created)
+	sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+
+	created := make(map[string]bool, len(sorted))
+	for _, path := range sorted {
+		created[path] = true
+	}
+
+	var removed []string
+	for _, path := range sorted {
+		info, err := os.Lstat(path)
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			err = tx.removeDir(path, info.Mode().Perm())
+		} else {
+			if info.Mode().IsRegular() && !fr.createdUnchanged(path, effects.CreatedHashes[path], effects.CreatedKeyed) {
+				continue
+			}
+
+			// Kenara alınan dosya, silinecek en üstteki dizinin dışında tutulur
+			asideDir := filepath.Dir(path)
+			for created[asideDir] {
+				asideDir = filepath.Dir(asideDir)
+			}
+			err = tx.remove(path, asideDir)
+		}
+		if err != nil {
+			fmt.Printf(lang.Get("remove_created_warning")+"\n", path, err)
+			continue
+		}
+
+		removed = append(removed, path)
+	}
+
+	return removed
+}
+
+// createdUnchanged, cp'nin oluşturduğu dosyanın içeriğinin kayıttaki
+// özetle eşleşip eşleşmediğini kontrol eder. Eşleşmiyorsa ya da özet yoksa
+// (eski kayıtlar) dosyanın korunduğu bildirilir.
+func (fr *FileRestorer) createdUnchanged(path, hash string, keyed bool) bool {
+	if hash == "" {
+		fmt.Printf(lang.Get("created_unverified_kept")+"\n", path)
+		return false
+	}
+
+	current, err := fr.backupManager.hashFile(path, keyed)
+	if err != nil {
+		fmt.Printf(lang.Get("remove_created_warning")+"\n", path, err)
+		return false
+	}
+	if current != hash {
+		fmt.Printf(lang.Get("created_modified_kept")+"\n", path)
+		return false
+	}
+
+	return true
+}
+
+// targetChanged, geri yüklenecek yolda işlemden sonra yeniden oluşturulmuş
+// veya değiştirilmiş bir içerik olup olmadığını bildirir. Yol yoksa, içeriği
+// yedekle aynıysa ya da cp'nin üzerine yazdığı bir hedef hâlâ kopyalanan
+// içeriği taşıyorsa değişmemiş sayılır. Özeti olmayan eski kayıtlarda var
+// olan hedefler değişmiş kabul edilir.
+func (fr *FileRestorer) targetChanged(record *BackupRecord, fileInfo BackupFileInfo) bool {
+	path := fileInfo.OriginalPath
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+
+	if fileInfo.LinkTarget != "" || info.Mode()&os.ModeSymlink != 0 {
+		current, err := os.Readlink(path)
+		return err != nil || current != fileInfo.LinkTarget
+	}
+	if !info.Mode().IsRegular() {
+		return true
+	}
+
+	if fileInfo.Hash != "" {
+		if hash, err := fr.backupManager.hashFile(path, fileInfo.Keyed); err == nil && hash == fileInfo.Hash {
+			return false
+		}
+	}
+
+	if effects := record.Effects; effects != nil && fileInfo.Role == roleOverwritten {
+		if expected := effects.CreatedHashes[path]; expected != "" {
+			if hash, err := fr.backupManager.hashFile(path, effects.CreatedKeyed); err == nil && hash == expected {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// restoreDirectories kayıtlı dizinlerden eksik olanları oluşturur ve
+// oluşturulanları döndürür. Mevcut dizinlere dokunulmaz. partial ise
+// oluşturulamayan dizinler uyarıyla atlanır, alt dizinleri denenmez ve
+// hatalarıyla birlikte ikinci değer olarak döner.
+func (fr *FileRestorer) restoreDirectories(tx *restoreTx, dirInfos []BackupDirInfo) ([]BackupDirInfo, map[string]error, error) {
+	sorted := make([]BackupDirInfo, len(dirInfos))
+	copy(sorted, dirInfos)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	var created []BackupDirInfo
+	failed := make(map[string]error)
+	for _, dirInfo := range sorted {
+		if failedDirFor(failed, dirInfo.Path) != nil {
+			continue
+		}
+		// Yerinde dizin olmayan bir yol varsa mkdirAll hata verir
+		if info, err := os.Stat(dirInfo.Path); err == nil && info.IsDir() {
+			continue
+		}
+
+		if err := tx.mkdirAll(dirInfo.Path); err != nil {
+			if !fr.partial {
+				return created, nil, err
+			}
+			fmt.Printf(lang.Get("file_restore_warning")+"\n", dirInfo.Path+string(filepath.Separator), err)
+			failed[dirInfo.Path] = err
+			continue
+		}
+		created = append(created, dirInfo)
+	}
+
+	return created, failed, nil
+}
+
+// failedDirFor, path oluşturulamayan dizinlerden birinin içindeyse o
+// dizinin hatasını döndürür.
+func failedDirFor(failed map[string]error, path string) error {
+	for dir, err := range failed {
+		if hasPathPrefix(path, dir) {
+			return err
+		}
+	}
 	return nil
 }
 
-func (fr *FileRestorer) ListBackups() error {
-	recordPath := filepath.Join(fr.backupManager.backupDir, "last_backup.json")
+// stageFile bir dosyayı hedefinin yanında geçici bir dosyaya geri yükler
+// ve geçici dosyanın yolunu döndürür. İçerik kayıttaki özetle eşleşmezse
+// geçici dosya silinir ve hata döner.
+func (fr *FileRestorer) stageFile(tx *restoreTx, fileInfo BackupFileInfo) (string, error) {
+	// Hedef dizinin var olduğunu kontrol et, yoksa oluştur
+	if err := tx.mkdirAll(filepath.Dir(fileInfo.OriginalPath)); err != nil {
+		return "", err
+	}
+
+	if fileInfo.LinkTarget != "" {
+		return stageSymlink(fileInfo)
+	}
 
-	data, err := os.ReadFile(recordPath)
+	// Yedekleme dosyasının var olduğunu kontrol et
+	backup, err := fr.backupManager.OpenBackup(fileInfo)
 	if err != nil {
-		return fmt.Errorf(lang.Get("backup_record_not_found"), err)
+		return "", fmt.Errorf(lang.Get("backup_file_not_found"), err)
+	}
+	defer backup.Close()
+
+	// Eski kayıtlarda izinler yedek dosyanın kendisinde tutulur
+	mode := fileInfo.Mode
+	if mode == 0 {
+		if info, err := os.Stat(fileInfo.BackupPath); err == nil {
+			mode = info.Mode()
+		}
 	}
 
-	var record BackupRecord
-	err = json.Unmarshal(data, &record)
+	reader, err := fr.backupManager.newVerifyingReader(backup, fileInfo)
 	if err != nil {
-		return fmt.Errorf(lang.Get("backup_record_read_error"), err)
+		return "", err
 	}
 
-	fmt.Printf("Son yedekleme: %s\n", record.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Komut: %s %v\n", record.Command, record.Args)
-	fmt.Printf("Yedeklenen dosyalar:\n")
+	tmpPath, err := stageFileFrom(reader, fileInfo.OriginalPath, mode, fileInfo.Meta, fileInfo.Sparse)
+	if err != nil {
+		return "", fmt.Errorf(lang.Get("file_copy_error"), err)
+	}
 
-	for _, fileInfo := range record.Files {
-		fmt.Printf("  - %s (%d bytes)\n", fileInfo.OriginalPath, fileInfo.Size)
+	return tmpPath, nil
+}
+
+// stageSymlink, bağı hedefin yanında geçici bir adla oluşturur. Aynı
+// hedefi gösteren bir bağ zaten varsa yapılacak bir şey yoktur.
+func stageSymlink(fileInfo BackupFileInfo) (string, error) {
+	dst := fileInfo.OriginalPath
+	if current, err := os.Readlink(dst); err == nil && current == fileInfo.LinkTarget {
+		return "", nil
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".sysundo-link-%d", time.Now().UnixNano()))
+	if err := os.Symlink(fileInfo.LinkTarget, tmpPath); err != nil {
+		return "", fmt.Errorf(lang.Get("symlink_create_error"), err)
+	}
+
+	if fileInfo.Meta != nil && fileInfo.Meta.Owner != nil {
+		if err := os.Lchown(tmpPath, fileInfo.Meta.Owner.UID, fileInfo.Meta.Owner.GID); err != nil {
+			fmt.Printf(lang.Get("metadata_warning")+"\n", dst,
+				fmt.Sprintf(lang.Get("owner_restore_warning"), fileInfo.Meta.Owner.UID, fileInfo.Meta.Owner.GID, permissionHint(err)))
+		}
+	}
+
+	return tmpPath, nil
+}
+
+// stageLink, dst'nin yanında existing'e sabit bağ olan geçici bir yol
+// oluşturur.
+func stageLink(existing, dst string) (string, error) {
+	tmpPath := filepath.Join(filepath.Dir(dst), fmt.Sprintf(".sysundo-link-%d", time.Now().UnixNano()))
+	if err := os.Link(existing, tmpPath); err != nil {
+		return "", err
+	}
+
+	return tmpPath, nil
+}
+
+// stageFileFrom, içeriği hedefin yanında geçici bir dosyaya yazar, diske
+// işler ve izinleriyle diğer özniteliklerini uygular. İçerik tamamı
+// okunamadıysa (bozuk veya şifresi çözülemeyen yedek) geçici dosya silinir;
+// hedefe hiç dokunulmaz. Sıfır blokları sadece yedeklenirken seyrek olan
+// dosyalarda boşluk bırakılır; takas dosyaları veya önceden ayrılmış
+// veritabanı dosyaları gibi yoğun dosyalar yoğun kalır.
+func stageFileFrom(r io.Reader, dst string, mode os.FileMode, meta *FileMetadata, sparse bool) (string, error) {
+	tmpFile, err := os.CreateTemp(filepath.Dir(dst), ".sysundo-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
+
+	if sparse {
+		writer := newSparseWriter(tmpFile)
+		_, err = io.Copy(writer, r)
+		if err == nil {
+			err = writer.Finish()
+		}
+	} else {
+		_, err = io.Copy(tmpFile, r)
+	}
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if mode == 0 {
+		mode = 0644
+	}
+	var warning error
+	if err == nil {
+		warning, err = applyMetadata(tmpPath, mode, meta)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if warning != nil {
+		fmt.Printf(lang.Get("metadata_warning")+"\n", dst, warning)
+	}
+
+	return tmpPath, nil
+}
+
+func (fr *FileRestorer) ListBackups(filter HistoryFilter) error {
+	records, err := fr.backupManager.history.Load()
+	if err != nil {
+		return err
+	}
+
+	// En yeni işlem en üstte gösterilir
+	shown := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if !filter.Match(record) {
+			continue
+		}
+
+		var totalSize int64
+		for _, fileInfo := range record.Files {
+			totalSize += fileInfo.Size
+		}
+
+		if shown > 0 {
+			fmt.Println()
+		}
+		fmt.Printf(lang.Get("log_operation")+"\n", record.ID)
+		fmt.Printf("  "+lang.Get("log_date")+"\n", record.Timestamp.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("  "+lang.Get("log_command")+"\n", strings.TrimSpace(record.Command+" "+strings.Join(record.Args, " ")))
+		if record.WorkingDir != "" {
+			fmt.Printf("  "+lang.Get("log_working_dir")+"\n", record.WorkingDir)
+		}
+		fmt.Printf("  "+lang.Get("log_files")+"\n", len(record.Files), formatSize(totalSize))
+		if record.Pinned {
+			fmt.Println("  " + lang.Get("log_pinned"))
+		}
+		shown++
+	}
+
+	if shown == 0 {
+		fmt.Println(lang.Get("log_no_operations"))
 	}
 
 	return nil
 }
+
+// formatSize bayt sayısını okunabilir bir birimle gösterir.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
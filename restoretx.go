@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sysundo/lang"
+	"time"
+)
+
+// restoreTx, bir geri alma işleminin dosya sisteminde yaptığı her
+// değişikliği tersine çevrilebilecek şekilde kaydeder. Bir adım başarısız
+// olursa rollback yapılan her şeyi ters sırayla geri sarar; işlem
+// tamamlanırsa finish yerine konan dosyaların eski sürümlerini siler.
+// Geri yüklenen içerikten farklı eski sürümler finish'ten önce geçmişe
+// eklenmelidir (bkz. FileRestorer.saveReplaced); aksi halde kaybolurlar.
+type restoreTx struct {
+	undo     []rollbackStep
+	cleanup  []string       // Başarı halinde silinecek eski sürümler
+	replaced []replacedPath // Üzerine yazılan hedefler ve kenara alınan eski sürümleri
+}
+
+type replacedPath struct {
+	path  string
+	aside string
+}
+
+type rollbackStep struct {
+	path string
+	fn   func() error
+}
+
+func (tx *restoreTx) onRollback(path string, fn func() error) {
+	tx.undo = append(tx.undo, rollbackStep{path: path, fn: fn})
+}
+
+// rollback kaydedilen adımları ters sırayla geri alır. Zaten var olmayan
+// yollar (örneğin yerine taşınmış geçici dosyalar) hata sayılmaz.
+func (tx *restoreTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		step := tx.undo[i]
+		if err := step.fn(); err != nil && !os.IsNotExist(err) {
+			fmt.Printf(lang.Get("rollback_warning")+"\n", step.path, err)
+		}
+	}
+	tx.undo = nil
+	tx.cleanup = nil
+	tx.replaced = nil
+}
+
+func (tx *restoreTx) finish() {
+	for _, path := range tx.cleanup {
+		os.Remove(path)
+	}
+	tx.undo = nil
+	tx.cleanup = nil
+	tx.replaced = nil
+}
+
+// mkdirAll eksik dizinleri oluşturur ve her birini geri sarma için kaydeder.
+func (tx *restoreTx) mkdirAll(dir string) error {
+	var missing []string
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Lstat(current); err == nil {
+			break
+		}
+		missing = append(missing, current)
+		if parent := filepath.Dir(current); parent == current {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf(lang.Get("target_dir_create_error"), err)
+	}
+
+	// Üstten alta doğru kaydedilir, geri sarmada önce en alttaki silinir
+	for i := len(missing) - 1; i >= 0; i-- {
+		path := missing[i]
+		tx.onRollback(path, func() error { return os.Remove(path) })
+	}
+
+	return nil
+}
+
+// stage hazırlanmış geçici bir dosyayı geri sarma için kaydeder.
+func (tx *restoreTx) stage(tmpPath string) {
+	tx.onRollback(tmpPath, func() error { return os.Remove(tmpPath) })
+}
+
+// replace, hazırlanmış geçici dosyayı hedefin yerine taşır. Hedef varsa
+// eski sürümü önce sabit bağla (olmazsa yeniden adlandırarak) kenara alınır;
+// böylece geri sarmada eski içerik aynen geri konabilir.
+func (tx *restoreTx) replace(tmpPath, dst string) error {
+	aside := ""
+	linked := false
+	if info, err := os.Lstat(dst); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf(lang.Get("restore_target_is_dir"), dst)
+		}
+
+		aside = filepath.Join(filepath.Dir(dst), fmt.Sprintf(".sysundo-old-%d", time.Now().UnixNano()))
+		linked = os.Link(dst, aside) == nil
+		if !linked {
+			if err := os.Rename(dst, aside); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.Rename(tmpPath, dst); err != nil {
+		// Sabit bağla kenara alındıysa hedef hâlâ yerinde duruyor
+		if linked {
+			os.Remove(aside)
+		} else if aside != "" {
+			os.Rename(aside, dst)
+		}
+		return err
+	}
+
+	if aside != "" {
+		tx.cleanup = append(tx.cleanup, aside)
+		tx.replaced = append(tx.replaced, replacedPath{path: dst, aside: aside})
+		tx.onRollback(dst, func() error { return os.Rename(aside, dst) })
+	} else {
+		tx.onRollback(dst, func() error { return os.Remove(dst) })
+	}
+
+	return nil
+}
+
+// remove, oluşturulmuş bir dosyayı asideDir içine kenara alarak kaldırır.
+// Geri sarmada yerine konur, işlem tamamlanırsa silinir. asideDir, silinecek
+// dizinlerin dışında seçilir ki kenara alınan dosya onları boş bırakmasın.
+func (tx *restoreTx) remove(path, asideDir string) error {
+	aside := filepath.Join(asideDir, fmt.Sprintf(".sysundo-old-%d", time.Now().UnixNano()))
+	if err := os.Rename(path, aside); err != nil {
+		return err
+	}
+
+	tx.cleanup = append(tx.cleanup, aside)
+	tx.onRollback(path, func() error { return os.Rename(aside, path) })
+	return nil
+}
+
+// removeDir boş bir dizini siler; geri sarmada aynı izinlerle yeniden oluşturur.
+func (tx *restoreTx) removeDir(path string, perm os.FileMode) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	tx.onRollback(path, func() error { return os.Mkdir(path, perm) })
+	return nil
+}
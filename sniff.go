@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// sniffLength, içerik tespiti için okunan en fazla bayt sayısı
+const sniffLength = 8192
+
+// binarySignatures, ilk baytları metin gibi görünebilecek bilinen ikili
+// biçimlerin sihirli baytları. Başlığında NUL bulunan biçimler (PE, RIFF,
+// Ogg...) zaten NUL kontrolüyle yakalandığı için listede yok.
+var binarySignatures = [][]byte{
+	[]byte("\x7fELF"),             // ELF çalıştırılabilir
+	[]byte("\xfe\xed\xfa\xce"),    // Mach-O 32
+	[]byte("\xfe\xed\xfa\xcf"),    // Mach-O 64
+	[]byte("\xcf\xfa\xed\xfe"),    // Mach-O 64 (little endian)
+	[]byte("\xca\xfe\xba\xbe"),    // Mach-O fat / Java class
+	[]byte("\x89PNG\r\n\x1a\n"),   // PNG
+	[]byte("\xff\xd8\xff"),        // JPEG
+	[]byte("GIF87a"),              // GIF
+	[]byte("GIF89a"),              // GIF
+	[]byte("%PDF-"),               // PDF
+	[]byte("PK\x03\x04"),          // ZIP, JAR, DOCX...
+	[]byte("\x1f\x8b"),            // gzip
+	[]byte("\xfd7zXZ\x00"),        // xz
+	[]byte("\x28\xb5\x2f\xfd"),    // zstd
+	[]byte("7z\xbc\xaf\x27\x1c"),  // 7-Zip
+	[]byte("Rar!\x1a\x07"),        // RAR
+	[]byte("SQLite format 3\x00"), // SQLite
+	[]byte("\x00asm"),             // WebAssembly
+}
+
+// isTextFile dosyanın içeriğine bakarak metin olup olmadığına karar verir:
+// bilinen ikili imzalar ve NUL baytları ikili, shebang ve geçerli UTF-8
+// metin sayılır. Okunamayan dosyalar metin kabul edilmez.
+func isTextFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false
+	}
+
+	return isTextContent(buf[:n])
+}
+
+func isTextContent(data []byte) bool {
+	if len(data) == 0 {
+		return true
+	}
+
+	for _, signature := range binarySignatures {
+		if bytes.HasPrefix(data, signature) {
+			return false
+		}
+	}
+
+	// Betikler uzantısız olsa da metindir
+	if bytes.HasPrefix(data, []byte("#!")) {
+		return true
+	}
+
+	// UTF-16 metinler NUL içerir, BOM ile tanınır
+	if bytes.HasPrefix(data, []byte("\xff\xfe")) || bytes.HasPrefix(data, []byte("\xfe\xff")) {
+		return true
+	}
+
+	if bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+
+	// Okuma sınırında yarım kalan bir UTF-8 karakterini hesaba katma
+	if len(data) == sniffLength {
+		for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+			if utf8.Valid(data) {
+				return true
+			}
+			data = data[:len(data)-1]
+		}
+	}
+
+	if utf8.Valid(data) {
+		return true
+	}
+
+	// Latin-1 gibi tek baytlık kodlamalar: kontrol karakteri oranına bak
+	control := 0
+	for _, b := range data {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != '\b' && b != 0x1b {
+			control++
+		}
+	}
+
+	return control*10 < len(data)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// sparseBlockSize, geri yüklemede boşluk olarak atlanan blok boyutu. Çoğu
+// dosya sisteminin blok boyutuyla aynıdır; daha küçük sıfır blokları
+// boşluk oluşturmaz.
+const sparseBlockSize = 4096
+
+// isSparse, dosyanın diskte mantıksal boyutundan daha az yer kapladığını,
+// yani içinde boşluklar (hole) olduğunu bildirir.
+func isSparse(info os.FileInfo) bool {
+	allocated, ok := allocatedSize(info)
+	return ok && info.Mode().IsRegular() && allocated < info.Size()
+}
+
+// sparseCopy, src'nin sadece veri içeren bölgelerini SEEK_DATA/SEEK_HOLE
+// ile bulup dst'de aynı konumlara kopyalar; boşluklar dst'de de boşluk
+// olarak kalır. Sistem desteklemiyorsa hiçbir şey yazmadan hata döner.
+func sparseCopy(dst, src *os.File, size int64) error {
+	if !supportsSeekHole {
+		return errSeekHoleUnsupported
+	}
+
+	offset := int64(0)
+	for offset < size {
+		data, err := src.Seek(offset, seekData)
+		if err != nil {
+			if isNoMoreData(err) {
+				break
+			}
+			return err
+		}
+
+		hole, err := src.Seek(data, seekHole)
+		if err != nil {
+			return err
+		}
+
+		if _, err := src.Seek(data, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := dst.Seek(data, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(dst, src, hole-data); err != nil {
+			return err
+		}
+
+		offset = hole
+	}
+
+	// Dosya bir boşlukla bitiyorsa boyutu ancak Truncate ile ayarlanır
+	return dst.Truncate(size)
+}
+
+// sparseWriter, tamamen sıfır olan blokları yazmak yerine atlar ve böylece
+// hedef dosyada boşluk bırakır. Sadece yeni oluşturulmuş (boş) dosyalarda
+// kullanılabilir; atlanan bölgeler bu yüzden sıfır okunur.
+type sparseWriter struct {
+	file    *os.File
+	offset  int64
+	skipped int64 // Henüz Seek ile atlanmamış sıfır baytları
+}
+
+func newSparseWriter(file *os.File) *sparseWriter {
+	return &sparseWriter{file: file}
+}
+
+func (w *sparseWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// Blokları dosyadaki mutlak konuma göre hizala
+		n := sparseBlockSize - int(w.offset%sparseBlockSize)
+		if n > len(p) {
+			n = len(p)
+		}
+		chunk := p[:n]
+
+		if n == sparseBlockSize && isZeroBlock(chunk) {
+			w.skipped += int64(n)
+		} else {
+			if err := w.flushSkipped(); err != nil {
+				return written, err
+			}
+			if _, err := w.file.Write(chunk); err != nil {
+				return written, err
+			}
+		}
+
+		w.offset += int64(n)
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func (w *sparseWriter) flushSkipped() error {
+	if w.skipped == 0 {
+		return nil
+	}
+	_, err := w.file.Seek(w.skipped, io.SeekCurrent)
+	w.skipped = 0
+	return err
+}
+
+// Finish, dosya bir boşlukla bitiyorsa boyutunu ayarlar.
+func (w *sparseWriter) Finish() error {
+	if w.skipped == 0 {
+		return nil
+	}
+	w.skipped = 0
+	return w.file.Truncate(w.offset)
+}
+
+var zeroBlock = make([]byte, sparseBlockSize)
+
+func isZeroBlock(p []byte) bool {
+	return bytes.Equal(p, zeroBlock[:len(p)])
+}
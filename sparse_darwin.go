@@ -0,0 +1,7 @@
+package main
+
+// lseek whence değerleri (sys/unistd.h); macOS'ta Linux'un tersidir.
+const (
+	seekHole = 3
+	seekData = 4
+)
@@ -0,0 +1,9 @@
+//go:build linux || freebsd
+
+package main
+
+// lseek whence değerleri (linux/fs.h, sys/unistd.h)
+const (
+	seekData = 3
+	seekHole = 4
+)
@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+const supportsSeekHole = true
+
+var errSeekHoleUnsupported = errors.ErrUnsupported
+
+func allocatedSize(info os.FileInfo) (int64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int64(stat.Blocks) * 512, true
+}
+
+// isNoMoreData, SEEK_DATA'nın verilen konumdan sonra veri kalmadığını
+// bildiren ENXIO hatasını tanır.
+func isNoMoreData(err error) bool {
+	return errors.Is(err, syscall.ENXIO)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// Windows'ta SEEK_DATA/SEEK_HOLE yoktur; seyrek dosyalar normal kopyalanır.
+const (
+	supportsSeekHole = false
+	seekData         = 0
+	seekHole         = 0
+)
+
+var errSeekHoleUnsupported = errors.ErrUnsupported
+
+func allocatedSize(info os.FileInfo) (int64, bool) {
+	return 0, false
+}
+
+func isNoMoreData(err error) bool {
+	return false
+}
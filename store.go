@@ -0,0 +1,549 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sysundo/internal/atomicfile"
+	"sysundo/lang"
+	"time"
+)
+
+// ObjectStore, yedeklenen dosya içeriklerini SHA-256 özetleriyle adreslenmiş
+// olarak ~/.sysundo/objects altında saklar (objects/ab/cdef...). Aynı içerik
+// kaç kez yedeklenirse yedeklensin diskte tek bir kopya olarak durur; bir
+// nesnenin kaç geçmiş kaydı tarafından kullanıldığı References ile hesaplanır.
+//
+// Şifreleme açıksa nesneler keys ile yönetilen anahtarla AES-256-GCM
+// kullanılarak şifrelenir ve dosya adına ".enc" eklenir (objects/ab/cdef.gz.enc).
+// Şifreli nesnelerin adı düz SHA-256 değil, keyring'deki ad anahtarıyla
+// HMAC-SHA256'dır (bkz. newHasher).
+type ObjectStore struct {
+	dir  string
+	keys *KeyManager
+}
+
+func NewObjectStore(dir string, keys *KeyManager) *ObjectStore {
+	return &ObjectStore{
+		dir:  dir,
+		keys: keys,
+	}
+}
+
+// PutOptions, bir nesnenin depoya nasıl yazılacağını belirler.
+type PutOptions struct {
+	Codec   string
+	Level   int
+	Encrypt bool
+}
+
+// StoredObject, depodaki bir nesnenin bilgilerini tutar.
+type StoredObject struct {
+	Hash       string
+	Codec      string
+	Size       int64 // Orijinal içeriğin boyutu
+	StoredSize int64 // Diskte kapladığı boyut
+	Encrypted  bool
+	ModTime    time.Time
+}
+
+// objectPath, nesnenin verilen sıkıştırma biçimindeki yolunu döndürür.
+func (s *ObjectStore) objectPath(hash, codec string, encrypted bool) string {
+	name := hash[2:] + codecSuffixes[normalizeCodec(codec)]
+	if encrypted {
+		name += encryptedSuffix
+	}
+	return filepath.Join(s.dir, hash[:2], name)
+}
+
+// find, özete sahip nesneyi hangi biçimde saklanmış olursa olsun bulur.
+// Şifreli kopya varsa o tercih edilir.
+func (s *ObjectStore) find(hash string) (string, StoredObject, bool) {
+	if len(hash) < 3 {
+		return "", StoredObject{}, false
+	}
+
+	for _, encrypted := range []bool{true, false} {
+		for codec := range codecSuffixes {
+			path := s.objectPath(hash, codec, encrypted)
+			if info, err := os.Stat(path); err == nil {
+				return path, StoredObject{
+					Hash:       hash,
+					Codec:      codec,
+					StoredSize: info.Size(),
+					Encrypted:  encrypted,
+				}, true
+			}
+		}
+	}
+
+	return "", StoredObject{}, false
+}
+
+// Has, verilen özete sahip nesnenin depoda olup olmadığını kontrol eder.
+func (s *ObjectStore) Has(hash string) bool {
+	_, _, ok := s.find(hash)
+	return ok
+}
+
+// Put, srcPath içeriğini verilen biçimde sıkıştırarak depoya ekler. İçerik
+// zaten depodaysa yeni kopya tutulmaz ve mevcut nesnenin bilgileri döner.
+// Şifreli ve şifresiz nesneler farklı özetlerle adlandırıldığından şifreleme
+// açıkken içeriğin şifresiz bir kopyası yeniden kullanılmaz. Sıkıştırma
+// boyutu küçültmüyorsa (zaten sıkıştırılmış veriler) içerik ham olarak saklanır.
+func (s *ObjectStore) Put(srcPath string, opts PutOptions) (*StoredObject, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	object, tmpPath, err := s.writeFileTemp(srcPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	if _, existing, ok := s.find(object.Hash); ok {
+		existing.Size = object.Size
+		return &existing, nil
+	}
+
+	if object.Codec != codecNone && object.StoredSize >= object.Size {
+		os.Remove(tmpPath)
+		opts.Codec, opts.Level = codecNone, 0
+		object, tmpPath, err = s.writeFileTemp(srcPath, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmpPath)
+	}
+
+	if err := s.commit(tmpPath, object); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// PutBytes, bellekteki bir içeriği (örneğin büyük bir dosyanın bir parçasını)
+// depoya ekler. Özet yazmadan önce hesaplandığı için depoda zaten bulunan
+// içerik hiç yazılmaz; yarıda kalmış bir yedekleme bu sayede kaldığı yerden
+// devam eder.
+func (s *ObjectStore) PutBytes(data []byte, opts PutOptions) (*StoredObject, error) {
+	hasher, err := s.newHasher(opts.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+	hasher.Write(data)
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	if _, existing, ok := s.find(hash); ok {
+		existing.Size = int64(len(data))
+		return &existing, nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	object, tmpPath, err := s.writeTemp(bytes.NewReader(data), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	if object.Codec != codecNone && object.StoredSize >= object.Size {
+		os.Remove(tmpPath)
+		opts.Codec, opts.Level = codecNone, 0
+		object, tmpPath, err = s.writeTemp(bytes.NewReader(data), opts)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmpPath)
+	}
+
+	if err := s.commit(tmpPath, object); err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// newHasher, nesne adını veren özeti hesaplayan hash'i döndürür. Şifreli
+// nesneler (keyed) ad anahtarıyla HMAC-SHA256 kullanır: depoyu veya geçmişi
+// okuyabilen biri kısa içerikleri (.env değerleri gibi) tahmin edip düz
+// SHA-256 özetleriyle karşılaştıramaz.
+func (s *ObjectStore) newHasher(keyed bool) (hash.Hash, error) {
+	if !keyed {
+		return sha256.New(), nil
+	}
+
+	key, err := s.keys.NameKey()
+	if err != nil {
+		return nil, err
+	}
+	return hmac.New(sha256.New, key), nil
+}
+
+// commit, diske işlenmiş geçici dosyayı nesnenin kalıcı yoluna taşır ve
+// yeniden adlandırmanın kalıcı olması için dizinleri diske işler.
+func (s *ObjectStore) commit(tmpPath string, object *StoredObject) error {
+	objectPath := s.objectPath(object.Hash, object.Codec, object.Encrypted)
+	prefixDir := filepath.Dir(objectPath)
+
+	_, statErr := os.Stat(prefixDir)
+	if err := os.MkdirAll(prefixDir, 0755); err != nil {
+		return err
+	}
+	if os.IsNotExist(statErr) {
+		if err := atomicfile.SyncDir(s.dir); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, objectPath); err != nil {
+		return err
+	}
+
+	return atomicfile.SyncDir(prefixDir)
+}
+
+func (s *ObjectStore) writeFileTemp(srcPath string, opts PutOptions) (*StoredObject, string, error) {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer srcFile.Close()
+
+	// Ham ve şifresiz nesneler kaynağın bire bir kopyasıdır, klonlanabilir
+	if normalizeCodec(opts.Codec) == codecNone && !opts.Encrypt {
+		return s.cloneTemp(srcFile)
+	}
+
+	return s.writeTemp(srcFile, opts)
+}
+
+// cloneTemp, kaynağı deponun içinde geçici bir dosyaya klonlar (bkz.
+// cloneFile) ve özeti klondan hesaplar. Klon kaynaktan bağımsız bir anlık
+// görüntü olduğu için, bu sırada kaynak değişse bile nesnenin adı içeriğiyle
+// tutarlı kalır.
+func (s *ObjectStore) cloneTemp(src *os.File) (*StoredObject, string, error) {
+	tmpFile, err := os.CreateTemp(s.dir, atomicfile.TempPrefix+"*")
+	if err != nil {
+		return nil, "", err
+	}
+	tmpPath := tmpFile.Name()
+
+	hasher := sha256.New()
+	_, err = cloneFile(tmpFile, src)
+	if err == nil {
+		_, err = tmpFile.Seek(0, io.SeekStart)
+	}
+	var size int64
+	if err == nil {
+		size, err = io.Copy(hasher, tmpFile)
+	}
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, "", err
+	}
+
+	return &StoredObject{
+		Hash:       hex.EncodeToString(hasher.Sum(nil)),
+		Codec:      codecNone,
+		Size:       size,
+		StoredSize: size,
+	}, tmpPath, nil
+}
+
+// writeTemp, içeriği sıkıştırarak (ve istenirse şifreleyerek) deponun
+// içinde geçici bir dosyaya yazar ve bu sırada orijinal içeriğin özetini
+// hesaplar.
+func (s *ObjectStore) writeTemp(src io.Reader, opts PutOptions) (*StoredObject, string, error) {
+	tmpFile, err := os.CreateTemp(s.dir, atomicfile.TempPrefix+"*")
+	if err != nil {
+		return nil, "", err
+	}
+	tmpPath := tmpFile.Name()
+
+	fail := func(err error) (*StoredObject, string, error) {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return nil, "", err
+	}
+
+	hasher, err := s.newHasher(opts.Encrypt)
+	if err != nil {
+		return fail(err)
+	}
+
+	var sink io.WriteCloser = nopWriteCloser{tmpFile}
+	if opts.Encrypt {
+		keyID, key, err := s.keys.CurrentKey()
+		if err != nil {
+			return fail(err)
+		}
+		sink, err = newEncryptWriter(tmpFile, keyID, key)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	encoder, err := newCodecWriter(opts.Codec, sink, opts.Level)
+	if err != nil {
+		return fail(err)
+	}
+
+	size, err := io.Copy(io.MultiWriter(encoder, hasher), src)
+	if closeErr := encoder.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := sink.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	var info os.FileInfo
+	if err == nil {
+		info, err = os.Stat(tmpPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, "", err
+	}
+
+	return &StoredObject{
+		Hash:       hex.EncodeToString(hasher.Sum(nil)),
+		Codec:      normalizeCodec(opts.Codec),
+		Size:       size,
+		StoredSize: info.Size(),
+		Encrypted:  opts.Encrypt,
+	}, tmpPath, nil
+}
+
+// Open, nesnenin içeriğini açılmış (şifresi ve sıkıştırması çözülmüş)
+// olarak okumak için açar.
+func (s *ObjectStore) Open(hash string) (io.ReadCloser, error) {
+	path, object, ok := s.find(hash)
+	if !ok {
+		return nil, fmt.Errorf(lang.Get("object_not_found"), hash)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var src io.Reader = file
+	if object.Encrypted {
+		src, err = newDecryptReader(file, s.keys)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	decoder, err := newCodecReader(object.Codec, src)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &stackedReadCloser{Reader: decoder, closers: []io.Closer{decoder, file}}, nil
+}
+
+// Reencrypt, depodaki tüm nesneleri geçerli anahtarla yeniden şifreler.
+// Şifresiz nesneler de şifrelenir. İçerik yazılırken özeti tekrar
+// hesaplanır; özeti tutmayan bir nesne değiştirilmez ve hata döner.
+//
+// Düz SHA-256 ile adlandırılmış nesneler (şifresizler ve ad anahtarından
+// önce şifrelenmiş olanlar) anahtarlı adlarıyla yazılır; eski ad → yeni ad
+// eşleşmeleri ve eski nesneler döndürülür. Eski nesneler, geçmiş yeni adlara
+// geçirilene kadar silinmez.
+func (s *ObjectStore) Reencrypt() (int, map[string]string, []StoredObject, error) {
+	objects, err := s.List()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	renamed := make(map[string]string)
+	var stale []StoredObject
+	count := 0
+	for _, object := range objects {
+		oldPath := s.objectPath(object.Hash, object.Codec, object.Encrypted)
+
+		reader, err := s.Open(object.Hash)
+		if err != nil {
+			return count, renamed, stale, err
+		}
+		plain := sha256.New()
+		updated, tmpPath, err := s.writeTemp(io.TeeReader(reader, plain), PutOptions{Codec: object.Codec, Encrypt: true})
+		reader.Close()
+		if err != nil {
+			return count, renamed, stale, err
+		}
+
+		rename := updated.Hash != object.Hash
+		if rename && hex.EncodeToString(plain.Sum(nil)) != object.Hash {
+			os.Remove(tmpPath)
+			return count, renamed, stale, fmt.Errorf(lang.Get("object_hash_mismatch"), object.Hash)
+		}
+
+		if err := s.commit(tmpPath, updated); err != nil {
+			os.Remove(tmpPath)
+			return count, renamed, stale, err
+		}
+		if rename {
+			renamed[object.Hash] = updated.Hash
+			stale = append(stale, object)
+		} else if oldPath != s.objectPath(updated.Hash, updated.Codec, true) {
+			os.Remove(oldPath)
+		}
+		count++
+	}
+
+	return count, renamed, stale, nil
+}
+
+// List, depodaki tüm nesneleri döndürür. Orijinal boyut depoda tutulmadığı
+// için Size alanı boş kalır.
+func (s *ObjectStore) List() ([]StoredObject, error) {
+	var objects []StoredObject
+
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		name := d.Name()
+		encrypted := strings.HasSuffix(name, encryptedSuffix)
+		name = strings.TrimSuffix(name, encryptedSuffix)
+		codec := codecNone
+		for c, suffix := range codecSuffixes {
+			if suffix != "" && strings.HasSuffix(name, suffix) {
+				codec = c
+				name = strings.TrimSuffix(name, suffix)
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		objects = append(objects, StoredObject{
+			Hash:       filepath.Base(filepath.Dir(path)) + name,
+			Codec:      codec,
+			StoredSize: info.Size(),
+			Encrypted:  encrypted,
+			ModTime:    info.ModTime(),
+		})
+		return nil
+	})
+
+	return objects, err
+}
+
+// Remove, nesneyi depodan siler.
+func (s *ObjectStore) Remove(object StoredObject) error {
+	path := s.objectPath(object.Hash, object.Codec, object.Encrypted)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	// Boş kalan önek dizinini de kaldır
+	os.Remove(filepath.Dir(path))
+	return nil
+}
+
+// StoreStats, deponun boyutunu, sıkıştırma ve tekilleştirme kazancını özetler.
+type StoreStats struct {
+	Operations   int   // Geçmişteki işlem sayısı
+	Files        int   // Geçmişteki dosya kaydı sayısı
+	LogicalSize  int64 // Tüm dosya kayıtlarının toplam boyutu
+	Objects      int   // Depodaki benzersiz nesne sayısı
+	Encrypted    int   // Şifreli nesne sayısı
+	OriginalSize int64 // Benzersiz nesnelerin sıkıştırılmamış boyutu
+	StoredSize   int64 // Nesnelerin diskte kapladığı boyut
+}
+
+// CompressionRatio, orijinal boyutun diskte kaplanan boyuta oranı
+func (st *StoreStats) CompressionRatio() float64 {
+	if st.StoredSize == 0 {
+		return 1
+	}
+	return float64(st.OriginalSize) / float64(st.StoredSize)
+}
+
+func (s *ObjectStore) Stats(records []BackupRecord) (*StoreStats, error) {
+	stats := &StoreStats{Operations: len(records)}
+
+	sizes := make(map[string]int64)
+	for _, record := range records {
+		for _, fileInfo := range record.Files {
+			stats.Files++
+			stats.LogicalSize += fileInfo.Size
+			for _, chunk := range fileInfo.Chunks {
+				sizes[chunk.Hash] = chunk.Size
+			}
+			if fileInfo.Hash != "" && len(fileInfo.Chunks) == 0 {
+				sizes[fileInfo.Hash] = fileInfo.Size
+			}
+		}
+	}
+
+	objects, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, object := range objects {
+		stats.Objects++
+		if object.Encrypted {
+			stats.Encrypted++
+		}
+		stats.StoredSize += object.StoredSize
+		stats.OriginalSize += sizes[object.Hash]
+	}
+
+	return stats, nil
+}
+
+// References, her nesnenin geçmişteki kaç dosya kaydı tarafından
+// kullanıldığını sayar. Sayısı sıfır olan nesneler güvenle silinebilir.
+func (s *ObjectStore) References(records []BackupRecord) map[string]int {
+	refs := make(map[string]int)
+	for _, record := range records {
+		for _, fileInfo := range record.Files {
+			for _, hash := range fileInfo.ObjectHashes() {
+				refs[hash]++
+			}
+		}
+	}
+	return refs
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sysundo/lang"
+)
+
+// verifyingReader, okunan içeriğin özetini ve boyutunu hesaplar; sona
+// gelindiğinde kayıttaki değerlerle eşleşmiyorsa io.EOF yerine hata döner.
+// Eski kayıtlarda özet bulunmadığı için sadece boyut kontrol edilir.
+// Anahtarlı kayıtlar için hasher ad anahtarıyla HMAC olmalıdır (bkz.
+// BackupManager.newVerifyingReader).
+type verifyingReader struct {
+	r        io.Reader
+	hasher   hash.Hash
+	size     int64
+	fileInfo BackupFileInfo
+}
+
+func (bm *BackupManager) newVerifyingReader(r io.Reader, fileInfo BackupFileInfo) (io.Reader, error) {
+	hasher, err := bm.store.newHasher(fileInfo.Keyed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifyingReader{
+		r:        r,
+		hasher:   hasher,
+		fileInfo: fileInfo,
+	}, nil
+}
+
+func (vr *verifyingReader) Read(p []byte) (int, error) {
+	n, err := vr.r.Read(p)
+	vr.hasher.Write(p[:n])
+	vr.size += int64(n)
+
+	if err == io.EOF {
+		if checkErr := vr.check(); checkErr != nil {
+			return n, checkErr
+		}
+	}
+
+	return n, err
+}
+
+func (vr *verifyingReader) check() error {
+	if vr.size != vr.fileInfo.Size {
+		return fmt.Errorf(lang.Get("backup_size_mismatch"), vr.size, vr.fileInfo.Size)
+	}
+
+	if vr.fileInfo.Hash != "" {
+		if sum := hex.EncodeToString(vr.hasher.Sum(nil)); sum != vr.fileInfo.Hash {
+			return fmt.Errorf(lang.Get("backup_checksum_mismatch"), sum, vr.fileInfo.Hash)
+		}
+	}
+
+	return nil
+}
+
+// VerifyIssue, doğrulamada bulunan bir sorunu tanımlar.
+type VerifyIssue struct {
+	RecordID     string
+	OriginalPath string
+	Hash         string // Eski kayıtlarda boş, BackupPath kullanılır
+	BackupPath   string
+	Err          error
+}
+
+// VerifyReport, sysundo verify çıktısı
+type VerifyReport struct {
+	Checked  int // Kontrol edilen dosya kaydı sayısı
+	Missing  []VerifyIssue
+	Corrupt  []VerifyIssue
+	Orphaned []StoredObject
+}
+
+func (r *VerifyReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Corrupt) == 0
+}
+
+// Verify, geçmişteki her dosya kaydının yedeğini kontrol eder: nesne
+// depoda yoksa eksik, içeriği kayıttaki özet veya boyutla eşleşmiyorsa
+// bozuk sayılır. Hiçbir kaydın kullanmadığı nesneler sahipsiz olarak
+// raporlanır. quick ise içerik okunmaz, sadece varlık kontrol edilir.
+func (bm *BackupManager) Verify(quick bool) (*VerifyReport, error) {
+	// gc'nin doğrulama sırasında nesne silmesini önle
+	lock, err := bm.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	records, err := bm.history.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{}
+
+	// Aynı nesneyi kullanan kayıtlar için içeriği bir kez oku
+	results := make(map[string]error)
+	for _, record := range records {
+		for _, fileInfo := range record.Files {
+			// Sembolik bağların içeriği yoktur
+			if fileInfo.LinkTarget != "" {
+				continue
+			}
+			report.Checked++
+
+			issue := VerifyIssue{
+				RecordID:     record.ID,
+				OriginalPath: fileInfo.OriginalPath,
+				Hash:         fileInfo.Hash,
+				BackupPath:   fileInfo.BackupPath,
+			}
+
+			if !bm.backupExists(fileInfo) {
+				issue.Err = fmt.Errorf(lang.Get("backup_missing"))
+				report.Missing = append(report.Missing, issue)
+				continue
+			}
+
+			if quick {
+				continue
+			}
+
+			key := fileInfo.Hash
+			if key == "" {
+				key = fileInfo.BackupPath
+			}
+
+			err, done := results[key]
+			if !done {
+				err = bm.checkBackup(fileInfo)
+				results[key] = err
+			}
+
+			if err != nil {
+				issue.Err = err
+				report.Corrupt = append(report.Corrupt, issue)
+			}
+		}
+	}
+
+	objects, err := bm.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := bm.store.References(records)
+	for _, object := range objects {
+		if refs[object.Hash] == 0 {
+			report.Orphaned = append(report.Orphaned, object)
+		}
+	}
+
+	return report, nil
+}
+
+func (bm *BackupManager) backupExists(fileInfo BackupFileInfo) bool {
+	if fileInfo.Hash != "" {
+		for _, hash := range fileInfo.ObjectHashes() {
+			if !bm.store.Has(hash) {
+				return false
+			}
+		}
+		return true
+	}
+
+	_, err := os.Stat(fileInfo.BackupPath)
+	return err == nil
+}
+
+// checkBackup yedeğin tamamını okuyarak özetini ve boyutunu doğrular.
+func (bm *BackupManager) checkBackup(fileInfo BackupFileInfo) error {
+	backup, err := bm.OpenBackup(fileInfo)
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	reader, err := bm.newVerifyingReader(backup, fileInfo)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,28 +13,78 @@ import (
 type FileWatcher struct {
 	backupManager *BackupManager
 	config        *Config
+	safety        string // safetyWarn, safetyAbort veya safetyStrict
 }
 
-type Config struct {
-	MaxFileSize   int64    // 10MB limit
-	SupportedExts []string // Desteklenen uzantılar
-	ExcludedExts  []string // Hariç tutulan uzantılar
-	TextFilesOnly bool     // Sadece metin dosyaları
+// BlockedError, komutun sysundo tarafından hiç çalıştırılmadığını belirtir.
+// Komutun kendisinin başarısız olmasından ayırt edilebilmesi için farklı bir
+// çıkış koduyla sonlanılır.
+type BlockedError struct {
+	Err error
 }
 
-func NewFileWatcher() *FileWatcher {
+func (e *BlockedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BlockedError) Unwrap() error {
+	return e.Err
+}
+
+// blocked, komutu çalıştırmadan dönmek için hatayı BlockedError ile sarar.
+func blocked(format string, args ...interface{}) error {
+	return &BlockedError{Err: fmt.Errorf(format, args...)}
+}
+
+// affectedFile, komutun etkileyeceği bir dosyayı tutar. Dosya bir dizin
+// argümanının altından geldiyse RootPath ve RelativePath doldurulur.
+type affectedFile struct {
+	Path         string
+	RootPath     string
+	RelativePath string
+	Role         string
+}
+
+// skippedFile, komutun etkileyeceği ama yedeklenmeyecek bir dosyayı ve
+// nedenini tutar.
+type skippedFile struct {
+	Path   string
+	Reason string
+}
+
+// affectedSet, bir komutun etkileyeceği dosyaları ve dizinleri toplar.
+// Okunamadığı için içeriği bilinmeyen yollar Unreadable'da tutulur ve
+// yedeklenmeyecek dosyalarla birlikte bildirilir.
+type affectedSet struct {
+	Files      []affectedFile
+	Dirs       []BackupDirInfo
+	Effects    OperationEffects
+	Unreadable []skippedFile
+}
+
+func (a *affectedSet) addUnreadable(path string, err error) {
+	a.Unreadable = append(a.Unreadable, skippedFile{path, fmt.Sprintf(lang.Get("skip_unreadable"), err)})
+}
+
+// NewFileWatcher yapılandırmayı yükler. Yapılandırma okunamıyor veya
+// geçersizse komut çalıştırılmaz: varsayılanlarla devam etmek şifreleme ve
+// güvenlik seviyesi ayarlarını sessizce devre dışı bırakırdı.
+func NewFileWatcher(strict bool) (*FileWatcher, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, blocked(lang.Get("config_invalid_abort"), err)
+	}
+
+	safety := config.Safety
+	if strict {
+		safety = safetyStrict
+	}
+
 	return &FileWatcher{
 		backupManager: NewBackupManager(),
-		config: &Config{
-			MaxFileSize: 10 * 1024 * 1024, // 10MB
-			SupportedExts: []string{
-				".txt", ".md", ".json", ".yaml", ".yml",
-				".sh", ".js", ".py",
-			},
-			ExcludedExts:  []string{".mp4", ".zip", ".tar", ".gz"},
-			TextFilesOnly: false,
-		},
-	}
+		config:        config,
+		safety:        safety,
+	}, nil
 }
 
 func (fw *FileWatcher) ExecuteWithBackup(args []string) error {
@@ -50,36 +101,174 @@ func (fw *FileWatcher) ExecuteWithBackup(args []string) error {
 	}
 
 	// Etkilenecek dosyaları bul
-	affectedFiles, err := fw.findAffectedFiles(command, commandArgs)
+	affected, err := fw.findAffectedFiles(command, commandArgs)
+	if err != nil {
+		return blocked(lang.Get("affected_files_not_found"), err)
+	}
+
+	// Yedeklenmeyecek dosyaları komut çalışmadan önce bildir
+	eligible, skipped := fw.planBackups(affected.Files)
+	skipped = append(affected.Unreadable, skipped...)
+	if len(skipped) > 0 {
+		fmt.Println(lang.Get("skip_report_header"))
+		for _, file := range skipped {
+			fmt.Printf(lang.Get("skip_report_line")+"\n", file.Path, file.Reason)
+		}
+		if fw.safety == safetyStrict {
+			return blocked(lang.Get("strict_abort"), len(skipped))
+		}
+	}
+
+	// Dosyaları yedekle ve işlemi kaydet
+	if err := fw.backupAffected(affected, eligible, command, commandArgs); err != nil {
+		return err
+	}
+
+	// Orijinal komutu çalıştır, ardından gerekiyorsa eski yedekleri buda
+	err = fw.executeCommand(command, commandArgs)
+	fw.backupManager.AutoGC(fw.config)
+	return err
+}
+
+// backupAffected, yedeklenecek dosyaları depoya ekler ve işlemi geçmişe
+// kaydeder. Depo kilidi sadece bu süre boyunca tutulur, komutun kendisi
+// çalışırken diğer sysundo süreçleri beklemez.
+func (fw *FileWatcher) backupAffected(affected *affectedSet, eligible map[string]bool, command string, commandArgs []string) error {
+	lock, err := fw.backupManager.Lock()
 	if err != nil {
-		return fmt.Errorf(lang.Get("affected_files_not_found"), err)
+		if fw.safety != safetyWarn {
+			return blocked(lang.Get("safety_lock_failed"), err)
+		}
+		fmt.Printf(lang.Get("lock_warning")+"\n", err)
+		return nil
 	}
+	defer lock.Unlock()
 
-	// Geçerli dosyaları filtrele ve yedekle
-	backupPaths := make(map[string]string)
-	for _, file := range affectedFiles {
-		if fw.shouldBackupFile(file) {
-			backupPath, err := fw.backupManager.BackupFile(file)
+	// Geçerli dosyaları yedekle
+	var fileInfos []BackupFileInfo
+	backedUp := make(map[string]bool)
+	linkGroups := make(map[string]int) // inode kimliği -> fileInfos içindeki ilk kayıt
+	for _, file := range affected.Files {
+		absPath, err := filepath.Abs(file.Path)
+		if err != nil || backedUp[absPath] || !eligible[absPath] {
+			continue
+		}
+
+		// Sembolik bağlar izlenmeden, bağ olarak kaydedilir
+		if isSymlink(file.Path) {
+			fileInfo, err := fw.backupManager.BackupSymlink(file.Path)
 			if err != nil {
-				fmt.Printf(lang.Get("backup_warning")+"\n", file, err)
-			} else {
-				absPath, _ := filepath.Abs(file)
-				backupPaths[absPath] = backupPath
-				fmt.Printf(lang.Get("backed_up")+"\n", file)
+				if fw.safety != safetyWarn {
+					return blocked(lang.Get("safety_backup_failed"), file.Path, err)
+				}
+				fmt.Printf(lang.Get("backup_warning")+"\n", file.Path, err)
+				continue
 			}
+			fileInfo.RootPath = file.RootPath
+			fileInfo.RelativePath = file.RelativePath
+			fileInfo.Role = file.Role
+			fileInfos = append(fileInfos, *fileInfo)
+			backedUp[absPath] = true
+			fmt.Printf(lang.Get("backed_up_symlink")+"\n", file.Path, fileInfo.LinkTarget)
+
+			if fw.config.ForPath(absPath).BackupSymlinkTargets {
+				if fileInfos, err = fw.backupLinkTarget(absPath, fileInfos, backedUp); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// Aynı inode'un başka bir sabit bağı zaten yedeklendiyse içeriği tekrar okuma
+		identity := ""
+		if info, err := os.Stat(file.Path); err == nil {
+			identity = fileIdentity(info)
+		}
+		if first, ok := linkGroups[identity]; ok && identity != "" {
+			fileInfo := fileInfos[first]
+			fileInfo.OriginalPath = absPath
+			fileInfo.RootPath = file.RootPath
+			fileInfo.RelativePath = file.RelativePath
+			fileInfo.Role = file.Role
+			fileInfo.LinkGroup = identity
+			fileInfos[first].LinkGroup = identity
+			fileInfos = append(fileInfos, fileInfo)
+			backedUp[absPath] = true
+			fmt.Printf(lang.Get("backed_up_hardlink")+"\n", file.Path, fileInfos[first].OriginalPath)
+			continue
+		}
+
+		fileInfo, err := fw.backupManager.BackupFile(file.Path, fw.config.ForPath(absPath))
+		if err != nil {
+			if fw.safety != safetyWarn {
+				return blocked(lang.Get("safety_backup_failed"), file.Path, err)
+			}
+			fmt.Printf(lang.Get("backup_warning")+"\n", file.Path, err)
+			continue
+		}
+
+		fileInfo.RootPath = file.RootPath
+		fileInfo.RelativePath = file.RelativePath
+		fileInfo.Role = file.Role
+		fileInfos = append(fileInfos, *fileInfo)
+		backedUp[absPath] = true
+		if identity != "" {
+			linkGroups[identity] = len(fileInfos) - 1
+		}
+		if file.Role == roleOverwritten {
+			fmt.Printf(lang.Get("backed_up_overwritten")+"\n", file.Path)
+		} else {
+			fmt.Printf(lang.Get("backed_up")+"\n", file.Path)
 		}
 	}
 
 	// Yedekleme kaydını oluştur
-	if len(backupPaths) > 0 {
-		err := fw.backupManager.CreateBackupRecord(backupPaths, command, commandArgs)
+	if len(fileInfos) > 0 || len(affected.Dirs) > 0 || !affected.Effects.IsEmpty() {
+		record, err := fw.backupManager.CreateBackupRecord(fileInfos, affected.Dirs, &affected.Effects, command, commandArgs)
 		if err != nil {
+			if fw.safety != safetyWarn {
+				return blocked(lang.Get("safety_record_failed"), err)
+			}
 			fmt.Printf(lang.Get("backup_record_warning")+"\n", err)
+		} else {
+			fmt.Printf(lang.Get("operation_recorded")+"\n", record.ID)
 		}
 	}
 
-	// Orijinal komutu çalıştır
-	return fw.executeCommand(command, commandArgs)
+	return nil
+}
+
+// backupLinkTarget, bir sembolik bağın işaret ettiği normal dosyayı da
+// politika izin veriyorsa yedekler. Hedef geri alma sırasında sadece yoksa
+// geri yüklenir. Hata sadece güvenlik seviyesi komutun durdurulmasını
+// gerektiriyorsa döner.
+func (fw *FileWatcher) backupLinkTarget(linkPath string, fileInfos []BackupFileInfo, backedUp map[string]bool) ([]BackupFileInfo, error) {
+	target, err := filepath.EvalSymlinks(linkPath)
+	if err != nil || backedUp[target] {
+		return fileInfos, nil
+	}
+	if ok, _ := fw.shouldBackupFile(target); !ok {
+		return fileInfos, nil
+	}
+
+	fileInfo, err := fw.backupManager.BackupFile(target, fw.config.ForPath(target))
+	if err != nil {
+		if fw.safety != safetyWarn {
+			return fileInfos, blocked(lang.Get("safety_backup_failed"), target, err)
+		}
+		fmt.Printf(lang.Get("backup_warning")+"\n", target, err)
+		return fileInfos, nil
+	}
+
+	fileInfo.Role = roleLinkTarget
+	backedUp[target] = true
+	fmt.Printf(lang.Get("backed_up_link_target")+"\n", target)
+	return append(fileInfos, *fileInfo), nil
+}
+
+func isSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
 }
 
 func (fw *FileWatcher) isWatchedCommand(command string) bool {
@@ -92,33 +281,292 @@ func (fw *FileWatcher) isWatchedCommand(command string) bool {
 	return false
 }
 
-func (fw *FileWatcher) findAffectedFiles(command string, args []string) ([]string, error) {
-	var files []string
+func (fw *FileWatcher) findAffectedFiles(command string, args []string) (*affectedSet, error) {
+	// GNU tablolarında olmayan seçenekler (BSD'ye özgü olanlar gibi) sadece
+	// abort ve strict seviyelerinde komutu durdurur
+	plan, err := parseCommandArgs(command, args)
+	if err != nil {
+		if fw.safety != safetyWarn {
+			return nil, err
+		}
+		fmt.Printf(lang.Get("parse_warning")+"\n", err)
+		plan = fallbackCommandPlan(command, args)
+	}
+
+	// mv dizinleri her zaman bütün olarak taşır
+	recursive := plan.Recursive || command == "mv"
+
+	// Var olan dosyaları filtrele, dizinleri özyinelemeli olarak dolaş
+	affected := &affectedSet{}
+	for _, path := range fw.expandPaths(plan.Sources()) {
+		// Komutlar argüman olarak verilen bağın kendisini siler/taşır, hedefini değil
+		info, err := os.Lstat(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				affected.addUnreadable(path, err)
+			}
+			continue
+		}
 
-	switch command {
-	case "rm":
-		files = fw.expandPaths(args)
-	case "mv":
-		if len(args) >= 1 {
-			// mv komutunda kaynak dosyalar etkilenir
-			files = fw.expandPaths(args[:len(args)-1])
+		if !info.IsDir() {
+			affected.Files = append(affected.Files, affectedFile{Path: path})
+			continue
 		}
-	case "cp":
-		if len(args) >= 1 {
-			// cp komutunda kaynak dosyalar yedeklenir
-			files = fw.expandPaths(args[:len(args)-1])
+
+		if recursive {
+			err := fw.walkDirectory(path, affected)
+			if err != nil {
+				return nil, err
+			}
+		} else if plan.RemoveDirs {
+			// rm -d sadece boş dizinleri siler
+			err := fw.addEmptyDirectory(path, info, affected)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	// Var olan dosyaları filtrele
-	var existingFiles []string
-	for _, file := range files {
-		if info, err := os.Stat(file); err == nil && !info.IsDir() {
-			existingFiles = append(existingFiles, file)
+	// mv/cp'nin oluşturacağı, taşıyacağı ve üzerine yazacağı yolları ekle
+	fw.findTransferEffects(plan, affected)
+
+	return affected, nil
+}
+
+// findTransferEffects, mv/cp'nin her kaynak için yapacağı değişikliği
+// (taşıma, yeni dosya oluşturma, üzerine yazma) hesaplar ve üzerine yazılacak
+// mevcut hedef dosyaları yedeklenmek üzere ekler.
+func (fw *FileWatcher) findTransferEffects(plan *commandPlan, affected *affectedSet) {
+	for _, transfer := range plan.Transfers {
+		srcInfo, err := os.Stat(transfer.Source)
+		if err != nil {
+			continue
+		}
+
+		if plan.Command == "mv" {
+			exists, replace := fw.destinationState(plan, srcInfo, transfer.Destination)
+			if exists && !replace {
+				continue
+			}
+
+			from, errFrom := filepath.Abs(transfer.Source)
+			to, errTo := filepath.Abs(transfer.Destination)
+			if errFrom != nil || errTo != nil {
+				continue
+			}
+			affected.Effects.Moved = append(affected.Effects.Moved, MovedPath{From: from, To: to})
+
+			if replace {
+				fw.addOverwrittenFile(transfer.Destination, affected)
+			}
+			continue
+		}
+
+		if !srcInfo.IsDir() {
+			fw.addCopiedFile(plan, transfer.Source, srcInfo, transfer.Destination, affected)
+			continue
+		}
+
+		// cp -r olmadan dizin kopyalamaz; -r ile hedef ağaçtaki her dosya kontrol edilir
+		if !plan.Recursive {
+			continue
 		}
+
+		filepath.WalkDir(transfer.Source, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(transfer.Source, path)
+			if err != nil {
+				return nil
+			}
+			destination := filepath.Join(transfer.Destination, relPath)
+
+			if d.IsDir() {
+				if _, err := os.Lstat(destination); os.IsNotExist(err) {
+					fw.addCreatedPath(destination, affected)
+				}
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+
+			fw.addCopiedFile(plan, path, info, destination, affected)
+			return nil
+		})
+	}
+}
+
+// addCopiedFile, cp'nin destination'ı yeni oluşturacağını ya da var olan
+// dosyanın üzerine yazacağını kayda geçirir.
+func (fw *FileWatcher) addCopiedFile(plan *commandPlan, source string, srcInfo os.FileInfo, destination string, affected *affectedSet) {
+	exists, replace := fw.destinationState(plan, srcInfo, destination)
+	if !exists {
+		fw.addCreatedFile(source, destination, affected)
+	} else if replace {
+		// cp var olan bir bağın hedefine yazar, bağın kendisi değişmez
+		if target, err := filepath.EvalSymlinks(destination); err == nil && isSymlink(destination) {
+			destination = target
+		}
+		fw.addOverwrittenFile(destination, affected)
+		fw.addExpectedHash(source, destination, affected)
+	}
+}
+
+// destinationState, hedefin var olup olmadığını ve komutun onun üzerine
+// yazıp yazmayacağını (-n ve -u kuralları dikkate alınarak) bildirir.
+func (fw *FileWatcher) destinationState(plan *commandPlan, srcInfo os.FileInfo, destination string) (bool, bool) {
+	destInfo, err := os.Stat(destination)
+	if err != nil {
+		_, lerr := os.Lstat(destination)
+		return lerr == nil, lerr == nil && !plan.NoClobber
+	}
+
+	if destInfo.IsDir() || plan.NoClobber || os.SameFile(srcInfo, destInfo) {
+		return true, false
+	}
+
+	if plan.UpdateOlder && !destInfo.ModTime().Before(srcInfo.ModTime()) {
+		return true, false
+	}
+
+	return true, true
+}
+
+func (fw *FileWatcher) addCreatedPath(path string, affected *affectedSet) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	affected.Effects.Created = append(affected.Effects.Created, absPath)
+}
+
+// addCreatedFile, cp'nin oluşturacağı dosyayı kaynağın içerik özetiyle
+// birlikte kaydeder. Geri alma, kopyayı sadece içeriği hâlâ bu özetle
+// eşleşiyorsa siler; sonradan değiştirilmiş bir kopya korunur.
+func (fw *FileWatcher) addCreatedFile(source, destination string, affected *affectedSet) {
+	absPath, err := filepath.Abs(destination)
+	if err != nil {
+		return
+	}
+	affected.Effects.Created = append(affected.Effects.Created, absPath)
+	fw.addExpectedHash(source, absPath, affected)
+}
+
+// addExpectedHash, cp'nin destination'a yazacağı içeriğin (kaynağın)
+// özetini kaydeder. Geri alma bu özetle hedefin işlemden sonra
+// değiştirilip değiştirilmediğini anlar.
+func (fw *FileWatcher) addExpectedHash(source, destination string, affected *affectedSet) {
+	absPath, err := filepath.Abs(destination)
+	if err != nil {
+		return
+	}
+
+	// cp -r sembolik bağları bağ olarak kopyalar; bağların içeriği yoktur
+	info, err := os.Stat(source)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	// Şifreleme herhangi bir yerde açıksa geçmişe düz özet yazılmaz
+	keyed := fw.config.encryptsAny()
+	hash, err := fw.backupManager.hashFile(source, keyed)
+	if err != nil {
+		return
+	}
+
+	if affected.Effects.CreatedHashes == nil {
+		affected.Effects.CreatedHashes = make(map[string]string)
+	}
+	affected.Effects.CreatedHashes[absPath] = hash
+	affected.Effects.CreatedKeyed = keyed
+}
+
+func (fw *FileWatcher) addOverwrittenFile(path string, affected *affectedSet) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	affected.Effects.Overwritten = append(affected.Effects.Overwritten, absPath)
+	affected.Files = append(affected.Files, affectedFile{
+		Path: path,
+		Role: roleOverwritten,
+	})
+}
+
+// addEmptyDirectory, rm -d ile silinecek boş bir dizini kayda ekler.
+func (fw *FileWatcher) addEmptyDirectory(path string, info os.FileInfo, affected *affectedSet) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		affected.addUnreadable(path, err)
+		return nil
+	}
+	if len(entries) > 0 {
+		return nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf(lang.Get("absolute_path_error"), err)
+	}
+
+	affected.Dirs = append(affected.Dirs, BackupDirInfo{
+		Path:         absPath,
+		RootPath:     absPath,
+		RelativePath: ".",
+		Mode:         modeBits(info),
+		Meta:         captureMetadata(absPath, info),
+	})
+	return nil
+}
+
+// walkDirectory, root altındaki tüm dosya ve dizinleri göreli yapılarıyla
+// birlikte affected içine ekler.
+func (fw *FileWatcher) walkDirectory(root string, affected *affectedSet) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf(lang.Get("absolute_path_error"), err)
 	}
 
-	return existingFiles, nil
+	return filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
+		// Okunamayan dizinlerin içeriği yedeklenemez ama komut yine de silebilir
+		if err != nil {
+			affected.addUnreadable(path, err)
+			return nil
+		}
+
+		relPath, err := filepath.Rel(absRoot, path)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				affected.addUnreadable(path, err)
+				return nil
+			}
+			affected.Dirs = append(affected.Dirs, BackupDirInfo{
+				Path:         path,
+				RootPath:     absRoot,
+				RelativePath: relPath,
+				Mode:         modeBits(info),
+				Meta:         captureMetadata(path, info),
+			})
+			return nil
+		}
+
+		affected.Files = append(affected.Files, affectedFile{
+			Path:         path,
+			RootPath:     absRoot,
+			RelativePath: relPath,
+		})
+		return nil
+	})
 }
 
 func (fw *FileWatcher) expandPaths(paths []string) []string {
@@ -139,41 +587,134 @@ func (fw *FileWatcher) expandPaths(paths []string) []string {
 	return expanded
 }
 
-func (fw *FileWatcher) shouldBackupFile(filePath string) bool {
+// planBackups, etkilenen dosyalardan hangilerinin yedekleneceğine komut
+// çalışmadan önce karar verir. Yedeklenmeyecek dosyalar nedenleriyle
+// döndürülür; var olmayan yollar korunacak bir şey olmadığından listelenmez.
+func (fw *FileWatcher) planBackups(files []affectedFile) (map[string]bool, []skippedFile) {
+	eligible := make(map[string]bool)
+	var skipped []skippedFile
+	seen := make(map[string]bool)
+
+	for _, file := range files {
+		absPath, err := filepath.Abs(file.Path)
+		if err != nil || seen[absPath] {
+			continue
+		}
+		seen[absPath] = true
+
+		// Sembolik bağlar sadece dışlama desenleriyle elenir
+		if isSymlink(file.Path) {
+			if matchesAnyPattern(absPath, fw.config.ForPath(absPath).ExcludePatterns) {
+				skipped = append(skipped, skippedFile{file.Path, lang.Get("skip_excluded_pattern")})
+			} else {
+				eligible[absPath] = true
+			}
+			continue
+		}
+
+		ok, reason := fw.shouldBackupFile(file.Path)
+		if ok {
+			eligible[absPath] = true
+		} else if reason != "" {
+			skipped = append(skipped, skippedFile{file.Path, reason})
+		}
+	}
+
+	return eligible, skipped
+}
+
+// shouldBackupFile, dosyanın politikaya göre yedeklenip yedeklenmeyeceğini
+// ve yedeklenmeyecekse kullanıcıya gösterilecek nedeni döndürür. Dosya yoksa
+// neden boştur.
+func (fw *FileWatcher) shouldBackupFile(filePath string) (bool, string) {
 	// Dosya var mı kontrol et
 	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return false, ""
+	}
 	if err != nil {
-		return false
+		return false, fmt.Sprintf(lang.Get("skip_unreadable"), err)
 	}
 
 	// Dizin mi kontrol et
 	if info.IsDir() {
-		return false
+		return false, ""
+	}
+	if !info.Mode().IsRegular() {
+		return false, lang.Get("skip_not_regular")
+	}
+
+	// Dosyanın bulunduğu dizin için geçerli politikayı al
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return false, fmt.Sprintf(lang.Get("skip_unreadable"), err)
+	}
+	config := fw.config.ForPath(absPath)
+
+	// Glob desenleri uzantı kurallarından önce gelir
+	if matchesAnyPattern(absPath, config.ExcludePatterns) {
+		return false, lang.Get("skip_excluded_pattern")
 	}
 
 	// Boyut kontrolü
-	if info.Size() > fw.config.MaxFileSize {
-		return false
+	// Sınırı aşan dosyalar sadece parçalı yedekleme açıksa yedeklenir
+	if info.Size() > config.MaxFileSize {
+		if !config.ChunkedBackup {
+			return false, fmt.Sprintf(lang.Get("skip_too_large"), formatSize(info.Size()), formatSize(config.MaxFileSize))
+		}
+		if config.MaxChunkedSize > 0 && info.Size() > config.MaxChunkedSize {
+			return false, fmt.Sprintf(lang.Get("skip_too_large_chunked"), formatSize(info.Size()), formatSize(config.MaxChunkedSize))
+		}
+	}
+
+	// İçeriği okunamayan dosyalar yedeklenemez
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, fmt.Sprintf(lang.Get("skip_unreadable"), err)
+	}
+	file.Close()
+
+	if matchesAnyPattern(absPath, config.IncludePatterns) {
+		return true, ""
 	}
 
 	// Uzantı kontrolü
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	// Hariç tutulan uzantılar kontrolü
-	for _, excludedExt := range fw.config.ExcludedExts {
+	for _, excludedExt := range config.ExcludedExts {
 		if ext == excludedExt {
-			return false
+			return false, fmt.Sprintf(lang.Get("skip_excluded_ext"), ext)
+		}
+	}
+
+	// Sadece metin modunda karar tamamen içeriğe göre verilir
+	if config.TextFilesOnly {
+		if isTextFile(filePath) {
+			return true, ""
 		}
+		return false, lang.Get("skip_binary")
 	}
 
 	// Desteklenen uzantılar kontrolü
-	for _, supportedExt := range fw.config.SupportedExts {
+	for _, supportedExt := range config.SupportedExts {
 		if ext == supportedExt {
-			return true
+			return true, ""
 		}
 	}
 
-	return false
+	// Akıllı politikada listede olmayan dosyalar (Makefile, .go, betikler...) içeriğe göre seçilir
+	if config.Policy == policySmart {
+		if isTextFile(filePath) {
+			return true, ""
+		}
+		return false, lang.Get("skip_binary")
+	}
+
+	if ext == "" {
+		return false, lang.Get("skip_no_ext")
+	}
+	return false, fmt.Sprintf(lang.Get("skip_unsupported_ext"), ext)
 }
 
 func (fw *FileWatcher) executeCommand(command string, args []string) error {
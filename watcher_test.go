@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestMain, SYSUNDO_TEST_MAIN ayarlıysa test ikilisini sysundo olarak
+// çalıştırır; böylece testler gerçek, ayrı süreçler başlatabilir.
+func TestMain(m *testing.M) {
+	if os.Getenv("SYSUNDO_TEST_MAIN") == "1" {
+		os.Args = append([]string{"sysundo"}, os.Args[1:]...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestParallelWatchers(t *testing.T) {
+	const watchers = 8
+
+	home := t.TempDir()
+	work := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Aynı içerikli dosyalar aynı nesneye yazılmak için yarışır
+	for i := 0; i < watchers; i++ {
+		content := fmt.Sprintf("file %d\n", i%3)
+		path := filepath.Join(work, fmt.Sprintf("f%d.txt", i))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, watchers)
+	outputs := make([][]byte, watchers)
+	for i := 0; i < watchers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "watch", "rm", fmt.Sprintf("f%d.txt", i))
+			cmd.Dir = work
+			cmd.Env = append(os.Environ(), "SYSUNDO_TEST_MAIN=1", "HOME="+home)
+			outputs[i], errs[i] = cmd.CombinedOutput()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("watcher %d failed: %v\n%s", i, err, outputs[i])
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, ".sysundo", "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var record BackupRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("malformed history line %q: %v", scanner.Text(), err)
+		}
+		if record.ID == "" {
+			t.Fatalf("history line without id: %q", scanner.Text())
+		}
+		if ids[record.ID] {
+			t.Fatalf("duplicate operation id %s", record.ID)
+		}
+		ids[record.ID] = true
+		if len(record.Files) != 1 {
+			t.Errorf("record %s has %d files, want 1", record.ID, len(record.Files))
+		}
+	}
+	if len(ids) != watchers {
+		t.Fatalf("history has %d records, want %d", len(ids), watchers)
+	}
+
+	report, err := NewBackupManager().Verify(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("verify found %d missing and %d corrupt backups", len(report.Missing), len(report.Corrupt))
+	}
+	if report.Checked != watchers {
+		t.Errorf("verify checked %d files, want %d", report.Checked, watchers)
+	}
+}